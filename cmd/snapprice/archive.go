@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/archive"
+	"github.com/mindsgn-studio/takealot-scraper/internal/config"
+	"github.com/mindsgn-studio/takealot-scraper/internal/database"
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+)
+
+// archiveFlags pick where raw pages are archived.
+type archiveFlags struct {
+	dir    string
+	gridFS bool
+}
+
+func (a *archiveFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&a.dir, "archive-dir", "", "archive every fetched page, gzipped, under this directory")
+	fs.BoolVar(&a.gridFS, "archive-gridfs", false, "archive every fetched page in the Mongo GridFS bucket \""+archive.DefaultBucket+"\"")
+}
+
+// open returns the configured archive, or nil when archiving is off.
+func (a *archiveFlags) open(ctx context.Context, cfg model.Config) (archive.Archiver, error) {
+	switch {
+	case a.dir != "" && a.gridFS:
+		return nil, errors.New("--archive-dir and --archive-gridfs cannot be used together")
+	case a.dir != "":
+		return archive.NewDir(a.dir)
+	case a.gridFS:
+		if err := config.RequireMongo(cfg); err != nil {
+			return nil, err
+		}
+		return archive.NewGridFS(ctx, cfg.MongoURI, cfg.DBName, cfg.DBOpTimeout)
+	default:
+		return nil, nil
+	}
+}
+
+func closeArchive(logger *slog.Logger, a archive.Archiver) {
+	ctx, cancel := context.WithTimeout(context.Background(), database.DefaultCloseTimeout)
+	defer cancel()
+	if err := a.Close(ctx); err != nil {
+		logger.Error("close archive", "error", err)
+	}
+}
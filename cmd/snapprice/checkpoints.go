@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/health"
+	"github.com/mindsgn-studio/takealot-scraper/internal/source/takealot"
+	"github.com/mindsgn-studio/takealot-scraper/internal/store"
+)
+
+func runCheckpoints(ctx context.Context, args []string) error {
+	if len(args) == 0 || (args[0] != "list" && args[0] != "reset") {
+		return errors.New("usage: snapprice checkpoints list|reset [flags]")
+	}
+	action := args[0]
+
+	fs := flag.NewFlagSet("checkpoints "+action, flag.ContinueOnError)
+	var g globalFlags
+	g.register(fs)
+	sourceName := fs.String("source", takealot.Name, "retailer whose checkpoints to use")
+	storeKind := fs.String("store", store.KindMongo, "store holding the checkpoints: mongo or memory")
+	keyword := fs.String("keyword", "", "only reset this keyword (default every keyword of the source)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	cfg, err := g.load()
+	if err != nil {
+		return err
+	}
+
+	logger := newLogger(cfg, "checkpoints").With("source", *sourceName)
+	st, err := openStore(ctx, *storeKind, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(logger, st)
+
+	var ready health.Checker
+	ready.Add("store", st.Ping)
+	srv, err := g.serve(ctx, logger, &ready)
+	if err != nil {
+		return err
+	}
+	if srv != nil {
+		defer srv.Close()
+	}
+
+	checkpoints, ok := st.(store.Checkpointer)
+	if !ok {
+		return fmt.Errorf("%s store does not keep checkpoints", *storeKind)
+	}
+
+	if action == "reset" {
+		if cfg.DryRun {
+			logger.Info("dry-run: would reset checkpoints", "keyword", *keyword)
+			return nil
+		}
+		var keywords []string
+		if *keyword != "" {
+			keywords = []string{*keyword}
+		}
+		n, err := checkpoints.DeleteCheckpoints(ctx, *sourceName, keywords...)
+		if err != nil {
+			return err
+		}
+		logger.Info("deleted checkpoints", "count", n)
+		return nil
+	}
+
+	list, err := checkpoints.ListCheckpoints(ctx, *sourceName)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEYWORD\tRUN\tPAGE\tCURSOR\tDONE\tUPDATED")
+	for _, cp := range list {
+		keyword := cp.Keyword
+		if keyword == "" {
+			keyword = "(pass)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%t\t%s\n", keyword, cp.RunID, cp.Page, cp.Cursor, cp.Done, cp.Updated.Format(time.RFC3339))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/config"
+)
+
+// runConfig prints the settings every other command would run with, after
+// the config file, environment and flags are merged.
+func runConfig(_ context.Context, args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return errors.New("usage: snapprice config print [flags]")
+	}
+
+	fs := flag.NewFlagSet("config print", flag.ContinueOnError)
+	var g globalFlags
+	g.register(fs)
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	cfg, err := g.load()
+	if err != nil {
+		return err
+	}
+	return config.Write(os.Stdout, cfg)
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/enrich"
+	"github.com/mindsgn-studio/takealot-scraper/internal/health"
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"github.com/mindsgn-studio/takealot-scraper/internal/schedule"
+	"github.com/mindsgn-studio/takealot-scraper/internal/scraper"
+	"github.com/mindsgn-studio/takealot-scraper/internal/source/takealot"
+	"github.com/mindsgn-studio/takealot-scraper/internal/store"
+	"github.com/mindsgn-studio/takealot-scraper/internal/watch"
+)
+
+const (
+	defaultDaemonJobs   = "takealot=6h,shoprite=24h,refresh=1h"
+	defaultDaemonJitter = 0.1
+
+	jobWatch   = "watch"
+	jobRefresh = "refresh"
+	jobEnrich  = "enrich"
+)
+
+// parseJobs reads a comma-separated list of name=interval pairs.
+func parseJobs(spec string) (map[string]time.Duration, error) {
+	jobs := make(map[string]time.Duration)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, every, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("job %q: want name=interval", part)
+		}
+		d, err := time.ParseDuration(every)
+		if err != nil {
+			return nil, fmt.Errorf("job %s: %w", name, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("job %s: interval must be positive", name)
+		}
+		if _, dup := jobs[name]; dup {
+			return nil, fmt.Errorf("job %s listed twice", name)
+		}
+		jobs[name] = d
+	}
+	if len(jobs) == 0 {
+		return nil, fmt.Errorf("no jobs given")
+	}
+	return jobs, nil
+}
+
+// activeJobs tracks the progress of the jobs currently running, so
+// readiness fails for a job that is stuck but not for one waiting on its
+// schedule.
+type activeJobs struct {
+	mu       sync.Mutex
+	progress map[string]func() time.Time
+	// drift holds each scrape job's latest engine, running or not, so a
+	// source stays degraded between passes.
+	drift map[string]func() []string
+}
+
+func (a *activeJobs) track(name string, last func() time.Time) (done func()) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.progress == nil {
+		a.progress = make(map[string]func() time.Time)
+	}
+	a.progress[name] = last
+	return func() {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		delete(a.progress, name)
+	}
+}
+
+// trackDrift reports the source's degraded state from degraded until the
+// source's next pass replaces it.
+func (a *activeJobs) trackDrift(name string, degraded func() []string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.drift == nil {
+		a.drift = make(map[string]func() []string)
+	}
+	a.drift[name] = degraded
+}
+
+// degraded lists the sources whose latest pass is degraded.
+func (a *activeJobs) degraded() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var sources []string
+	for _, degraded := range a.drift {
+		sources = append(sources, degraded()...)
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+func (a *activeJobs) check(after time.Duration) health.Check {
+	return func(ctx context.Context) error {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		names := make([]string, 0, len(a.progress))
+		for name := range a.progress {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if err := health.Stalled(a.progress[name], after)(ctx); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		return nil
+	}
+}
+
+func runDaemon(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	var g globalFlags
+	g.register(fs)
+	storeKind := fs.String("store", store.KindMongo, "store to write to and keep the schedule in: mongo, postgres or memory")
+	jobSpec := fs.String("jobs", defaultDaemonJobs, "comma-separated name=interval pairs; names are takealot, amazon, shoprite, "+jobWatch+", "+jobRefresh+" and "+jobEnrich)
+	jitter := fs.Float64("jitter", defaultDaemonJitter, "up to this fraction of each interval is randomly added to every due time")
+	var ef engineFlags
+	ef.register(fs)
+	var pf priorityFlags
+	pf.register(fs)
+	pf.registerKeywords(fs)
+	pf.registerItems(fs)
+	var enf enrichFlags
+	enf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := g.load()
+	if err != nil {
+		return err
+	}
+	jobs, err := parseJobs(*jobSpec)
+	if err != nil {
+		return fmt.Errorf("--jobs: %w", err)
+	}
+	if *jitter < 0 || *jitter > 1 {
+		return fmt.Errorf("--jitter must be between 0 and 1")
+	}
+	policy, err := pf.recrawl()
+	if err != nil {
+		return err
+	}
+	enrichOpts, err := enf.options()
+	if err != nil {
+		return err
+	}
+	logger := newLogger(cfg, "daemon")
+	if err := ef.setup(cfg, logger); err != nil {
+		return err
+	}
+
+	arc, err := ef.archive.open(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	if arc != nil {
+		defer closeArchive(logger, arc)
+	}
+
+	st, err := openStore(ctx, *storeKind, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(logger, st)
+
+	var timetable store.Timetable
+	if t, ok := st.(store.Timetable); ok && !cfg.DryRun {
+		timetable = t
+	} else if !cfg.DryRun {
+		logger.Warn("store cannot keep the schedule; every job will run on start", "store", *storeKind)
+	}
+	sched := schedule.New(timetable, logger)
+
+	var (
+		active  activeJobs
+		watcher *watch.Watcher
+	)
+	for name, every := range jobs {
+		job := schedule.Job{
+			Name:   name,
+			Every:  every,
+			Jitter: time.Duration(float64(every) * *jitter),
+		}
+		switch name {
+		case jobWatch, jobRefresh:
+			if watcher == nil {
+				watcher = watch.New(cfg, st, watch.Options{Priority: pf.weights(), ItemsPerPass: pf.itemsPerPass, Recrawl: policy}, logger)
+			}
+			job.Run = watcherJob(name, watcher, &active)
+		case jobEnrich:
+			e, err := newEnricher(cfg, st, *storeKind, &ef.http, enrichOpts, logger.With("source", takealot.Name))
+			if err != nil {
+				return fmt.Errorf("--jobs: %w", err)
+			}
+			job.Run = enrichJob(e, &active)
+		default:
+			source, opts, err := newSource(name, cfg, &ef.http)
+			if err != nil {
+				return fmt.Errorf("--jobs: %w", err)
+			}
+			ef.apply(&opts)
+			opts.Priority = pf.weights()
+			opts.KeywordsPerPass = pf.keywordsPerPass
+			opts.Recrawl = policy
+			if arc != nil {
+				opts.Archive = arc
+			}
+			job.Run = scrapeJob(cfg, source, opts, st, logger.With("source", name), &active)
+		}
+		if err := sched.Add(job); err != nil {
+			return err
+		}
+	}
+
+	var ready health.Checker
+	ready.Add("store", st.Ping)
+	ready.Add("circuits", health.Circuits(func() []string {
+		hosts := ef.http.openCircuits()
+		if watcher != nil {
+			hosts = append(hosts, watcher.OpenCircuits()...)
+		}
+		return hosts
+	}))
+	ready.Add("progress", active.check(g.stallAfter))
+	ready.Add("drift", health.Degraded(active.degraded))
+	srv, err := g.serve(ctx, logger, &ready)
+	if err != nil {
+		return err
+	}
+	if srv != nil {
+		defer srv.Close()
+	}
+
+	logger.Info("daemon started", "jobs", *jobSpec)
+	return sched.Run(ctx)
+}
+
+// scrapeJob runs one pass of source with a fresh engine each time, so drift
+// and ledger counts start from zero.
+func scrapeJob(cfg model.Config, source scraper.Source, opts scraper.Options, st store.Store, logger *slog.Logger, active *activeJobs) func(context.Context) error {
+	return func(ctx context.Context) error {
+		engine := scraper.NewEngine(cfg, source, opts, st, logger)
+		defer active.track(source.Name(), engine.LastProgress)()
+		active.trackDrift(source.Name(), engine.Degraded)
+
+		err := engine.Run(ctx)
+		drift := engine.Drift()
+		if drift.Degraded {
+			logger.Warn("source degraded", "drifted_pages", drift.Pages, "skipped_products", drift.Products)
+		}
+		return err
+	}
+}
+
+func enrichJob(e *enrich.Enricher, active *activeJobs) func(context.Context) error {
+	return func(ctx context.Context) error {
+		defer active.track(jobEnrich, e.LastProgress)()
+		return e.Run(ctx)
+	}
+}
+
+func watcherJob(name string, w *watch.Watcher, active *activeJobs) func(context.Context) error {
+	return func(ctx context.Context) error {
+		defer active.track(name, w.LastProgress)()
+		if name == jobWatch {
+			return w.Watch(ctx)
+		}
+		return w.Refresh(ctx)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/enrich"
+	"github.com/mindsgn-studio/takealot-scraper/internal/health"
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"github.com/mindsgn-studio/takealot-scraper/internal/source/takealot"
+	"github.com/mindsgn-studio/takealot-scraper/internal/store"
+)
+
+// enrichFlags tune the product detail stage; they are shared by enrich and
+// daemon.
+type enrichFlags struct {
+	maxAge  time.Duration
+	perPass int
+}
+
+func (f *enrichFlags) register(fs *flag.FlagSet) {
+	fs.DurationVar(&f.maxAge, "details-max-age", enrich.DefaultMaxAge, "product details older than this are fetched again")
+	fs.IntVar(&f.perPass, "details-per-pass", 0, "fetch details of only this many items per pass, missing and stalest first (default all)")
+}
+
+func (f *enrichFlags) options() (enrich.Options, error) {
+	if f.maxAge <= 0 {
+		return enrich.Options{}, fmt.Errorf("--details-max-age must be positive")
+	}
+	return enrich.Options{MaxAge: f.maxAge, ItemsPerPass: f.perPass}, nil
+}
+
+// newEnricher fetches Takealot product details through hopts, sharing its
+// per-host rate limit with any Takealot scrape.
+func newEnricher(cfg model.Config, st store.Store, kind string, hopts *httpOptions, opts enrich.Options, logger *slog.Logger) (*enrich.Enricher, error) {
+	details, ok := st.(store.Detailer)
+	if !ok {
+		return nil, fmt.Errorf("%s store does not keep product details", kind)
+	}
+	sc := cfg.Source(takealot.Name)
+	source := takealot.New(sc, hopts.transport(takealot.Host, sc))
+	return enrich.New(cfg, source, st, details, opts, logger), nil
+}
+
+func runEnrich(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("enrich", flag.ContinueOnError)
+	var g globalFlags
+	g.register(fs)
+	storeKind := fs.String("store", store.KindMongo, "store holding items and product details: mongo or memory")
+	var hopts httpOptions
+	hopts.register(fs)
+	var f enrichFlags
+	f.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := g.load()
+	if err != nil {
+		return err
+	}
+	opts, err := f.options()
+	if err != nil {
+		return err
+	}
+	logger := newLogger(cfg, "enrich").With("source", takealot.Name)
+	if err := hopts.setup(cfg, logger); err != nil {
+		return err
+	}
+
+	st, err := openStore(ctx, *storeKind, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(logger, st)
+
+	e, err := newEnricher(cfg, st, *storeKind, &hopts, opts, logger)
+	if err != nil {
+		return err
+	}
+	var ready health.Checker
+	ready.Add("store", st.Ping)
+	ready.Add("circuits", health.Circuits(hopts.openCircuits))
+	ready.Add("progress", health.Stalled(e.LastProgress, g.stallAfter))
+	srv, err := g.serve(ctx, logger, &ready)
+	if err != nil {
+		return err
+	}
+	if srv != nil {
+		defer srv.Close()
+	}
+
+	return e.Run(ctx)
+}
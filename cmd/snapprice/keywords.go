@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/catalogue"
+	"github.com/mindsgn-studio/takealot-scraper/internal/health"
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"github.com/mindsgn-studio/takealot-scraper/internal/store"
+)
+
+const defaultKeywordsAddedBy = "cli"
+
+var keywordActions = []string{"list", "add", "disable", "enable", "import", "export"}
+
+func runKeywords(ctx context.Context, args []string) error {
+	usage := errors.New("usage: snapprice keywords " + strings.Join(keywordActions, "|") + " [flags] [keyword... | file]")
+	if len(args) == 0 {
+		return usage
+	}
+	action := args[0]
+	if !slices.Contains(keywordActions, action) {
+		return usage
+	}
+
+	fs := flag.NewFlagSet("keywords "+action, flag.ContinueOnError)
+	var g globalFlags
+	g.register(fs)
+	storeKind := fs.String("store", store.KindMongo, "store holding the keyword catalogue: mongo or memory")
+	sourceName := fs.String("source", "", "list: only show keywords searched on this retailer, with its stats (default every retailer)")
+	category := fs.String("category", "", "add, import: category of the keywords (import: for seed lines without one)")
+	sources := fs.String("sources", "", "add, import: comma-separated retailers to search the keywords on (default every retailer)")
+	addedBy := fs.String("by", defaultKeywordsAddedBy, "add, import: who is adding the keywords")
+	format := fs.String("format", catalogue.FormatSeed, "import, export: file format, seed or json")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	cfg, err := g.load()
+	if err != nil {
+		return err
+	}
+
+	logger := newLogger(cfg, "keywords")
+	st, err := openStore(ctx, *storeKind, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(logger, st)
+
+	var ready health.Checker
+	ready.Add("store", st.Ping)
+	srv, err := g.serve(ctx, logger, &ready)
+	if err != nil {
+		return err
+	}
+	if srv != nil {
+		defer srv.Close()
+	}
+
+	cat, ok := st.(store.Catalogue)
+	if !ok {
+		return fmt.Errorf("%s store does not keep a keyword catalogue", *storeKind)
+	}
+
+	switch action {
+	case "list":
+		keywords, err := cat.ListKeywords(ctx)
+		if err != nil {
+			return err
+		}
+		return printKeywords(os.Stdout, keywords, *sourceName)
+
+	case "export":
+		keywords, err := cat.ListKeywords(ctx)
+		if err != nil {
+			return err
+		}
+		return catalogue.Write(os.Stdout, *format, keywords)
+
+	case "enable", "disable":
+		if fs.NArg() == 0 {
+			return fmt.Errorf("%s takes one or more keywords", action)
+		}
+		for _, keyword := range fs.Args() {
+			if cfg.DryRun {
+				logger.Info("dry-run: would "+action+" keyword", "keyword", keyword)
+				continue
+			}
+			if err := cat.SetKeywordEnabled(ctx, keyword, action == "enable"); err != nil {
+				if errors.Is(err, store.ErrNotFound) {
+					return fmt.Errorf("keyword %q is not in the catalogue", keyword)
+				}
+				return err
+			}
+			logger.Info(action+"d keyword", "keyword", keyword)
+		}
+		return nil
+	}
+
+	var keywords []model.Keyword
+	if action == "add" {
+		if fs.NArg() == 0 {
+			return errors.New("add takes one or more keywords")
+		}
+		for _, keyword := range fs.Args() {
+			if keyword = strings.TrimSpace(keyword); keyword != "" {
+				keywords = append(keywords, model.Keyword{Keyword: keyword, Category: *category, Enabled: true})
+			}
+		}
+	} else {
+		keywords, err = readKeywordFile(fs.Args(), cfg.BrandFile, *format, *category)
+		if err != nil {
+			return err
+		}
+	}
+
+	only := splitList(*sources)
+	for i := range keywords {
+		if len(only) > 0 {
+			keywords[i].Sources = only
+		}
+		if keywords[i].AddedBy == "" || action == "add" {
+			keywords[i].AddedBy = *addedBy
+		}
+	}
+	if cfg.DryRun {
+		logger.Info("dry-run: would add keywords", "count", len(keywords))
+		return nil
+	}
+	added, err := cat.AddKeywords(ctx, keywords...)
+	if err != nil {
+		return err
+	}
+	logger.Info("added keywords", "added", added, "already_present", len(keywords)-added)
+	return nil
+}
+
+// readKeywordFile reads the file named in args, or the brand file when
+// none is named.
+func readKeywordFile(args []string, brandFile, format, category string) ([]model.Keyword, error) {
+	name := brandFile
+	switch len(args) {
+	case 0:
+	case 1:
+		name = args[0]
+	default:
+		return nil, errors.New("import takes at most one file")
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	keywords, err := catalogue.Read(f, format, category)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return keywords, nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// printKeywords lists keywords with their stats for source, or their stats
+// summed over every source when source is empty.
+func printKeywords(out io.Writer, keywords []model.Keyword, source string) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEYWORD\tCATEGORY\tENABLED\tSOURCES\tADDED BY\tLAST CRAWLED\tCRAWLS\tPAGES\tLISTINGS\tNEW")
+	for _, k := range keywords {
+		if source != "" && !k.AppliesTo(source) {
+			continue
+		}
+		var total model.KeywordStats
+		for s, stats := range k.Stats {
+			if source != "" && s != source {
+				continue
+			}
+			if stats.LastCrawled.After(total.LastCrawled) {
+				total.LastCrawled = stats.LastCrawled
+			}
+			total.Crawls += stats.Crawls
+			total.Pages += stats.Pages
+			total.Listings += stats.Listings
+			total.NewItems += stats.NewItems
+		}
+		sources, lastCrawled := "all", "-"
+		if len(k.Sources) > 0 {
+			sources = strings.Join(k.Sources, ",")
+		}
+		if !total.LastCrawled.IsZero() {
+			lastCrawled = total.LastCrawled.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%t\t%s\t%s\t%s\t%d\t%d\t%d\t%d\n",
+			k.Keyword, k.Category, k.Enabled, sources, k.AddedBy, lastCrawled,
+			total.Crawls, total.Pages, total.Listings, total.NewItems)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/config"
+	"github.com/mindsgn-studio/takealot-scraper/internal/database"
+	"github.com/mindsgn-studio/takealot-scraper/internal/health"
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"github.com/mindsgn-studio/takealot-scraper/internal/store"
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{"scrape", "crawl a retailer's search results for every keyword", runScrape},
+	{"daemon", "run scrapes and price checks on a schedule", runDaemon},
+	{"watch", "re-check prices of watched items", runWatch},
+	{"refresh", "re-check prices of every item", runRefresh},
+	{"enrich", "fetch product details of Takealot items", runEnrich},
+	{"sync", "copy items and prices from Mongo to Postgres", runSync},
+	{"stats", "print item and price counts", runStats},
+	{"checkpoints", "list or reset saved crawl progress", runCheckpoints},
+	{"reprocess", "re-run extraction over archived pages", runReprocess},
+	{"runs", "list or compare recorded scrape runs", runRuns},
+	{"keywords", "list, add, disable, enable, import or export catalogue keywords", runKeywords},
+	{"config", "print the effective settings", runConfig},
+}
+
+// globalFlags are accepted by every subcommand.
+type globalFlags struct {
+	configFile string
+	envFile    string
+	logLevel   string
+	logFormat  string
+	dryRun     bool
+	listen     string
+	stallAfter time.Duration
+	// overrides are -set key=value pairs, applied in order.
+	overrides [][2]string
+}
+
+func (g *globalFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&g.configFile, "config", "", "YAML config file (default $SNAPPRICE_CONFIG or "+config.DefaultFile+" if present)")
+	fs.StringVar(&g.envFile, "env-file", "", "env file to load variables from (default .env if present)")
+	fs.StringVar(&g.logLevel, "log-level", "", "log level: debug, info, warn or error (default $LOG_LEVEL or "+config.DefaultLogLevel+")")
+	fs.StringVar(&g.logFormat, "log-format", "", "log output: text or json (default $LOG_FORMAT or "+config.DefaultLogFormat+")")
+	fs.BoolVar(&g.dryRun, "dry-run", false, "fetch and parse but do not write to any database")
+	fs.StringVar(&g.listen, "listen", "", "serve /metrics, /healthz and /readyz on this address, e.g. :9090 (default off)")
+	fs.DurationVar(&g.stallAfter, "stall-after", health.DefaultStallAfter, "/readyz fails once a long-running command has made no progress for this long")
+	fs.Func("set", "override a config file setting by its dotted key, e.g. http.max_retries=5 or sources.amazon.rate=0.5; repeatable (also $"+config.EnvPrefix+"<KEY>, e.g. $"+config.EnvName("sources.amazon.rate")+")", func(s string) error {
+		key, value, ok := strings.Cut(s, "=")
+		if !ok || key == "" {
+			return errors.New("want key=value")
+		}
+		g.overrides = append(g.overrides, [2]string{key, value})
+		return nil
+	})
+}
+
+// load layers the defaults, config file, environment and flags, in that
+// order.
+func (g *globalFlags) load() (model.Config, error) {
+	cfg, err := config.LoadConfig(config.Options{
+		File:    g.configFile,
+		EnvFile: g.envFile,
+		Sources: sourceDefaults(),
+	})
+	if err != nil {
+		return model.Config{}, err
+	}
+	if g.logLevel != "" {
+		cfg.LogLevel = g.logLevel
+	}
+	if g.logFormat != "" {
+		cfg.LogFormat = g.logFormat
+	}
+	for _, o := range g.overrides {
+		if err := config.Set(&cfg, o[0], o[1]); err != nil {
+			return model.Config{}, fmt.Errorf("-set %s: %w", o[0], err)
+		}
+	}
+	if err := config.Validate(cfg); err != nil {
+		return model.Config{}, err
+	}
+	cfg.DryRun = g.dryRun
+	return cfg, nil
+}
+
+func openStore(ctx context.Context, kind string, cfg model.Config) (store.Store, error) {
+	st, err := store.Open(ctx, kind, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("open %s store: %w", kind, err)
+	}
+	return st, nil
+}
+
+func closeStore(logger *slog.Logger, st store.Store) {
+	ctx, cancel := context.WithTimeout(context.Background(), database.DefaultCloseTimeout)
+	defer cancel()
+	if err := st.Close(ctx); err != nil {
+		logger.Error("close store", "error", err)
+	}
+}
+
+// newLogger writes to stdout in the configured format and level and tags
+// every record with the command name. cfg must have passed load.
+func newLogger(cfg model.Config, command string) *slog.Logger {
+	level, _ := config.ParseLogLevel(cfg.LogLevel)
+	opts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler = slog.NewTextHandler(os.Stdout, opts)
+	if cfg.LogFormat == "json" {
+		handler = slog.NewJSONHandler(os.Stdout, opts)
+	}
+	logger := slog.New(handler).With("cmd", command)
+	slog.SetDefault(logger)
+	return logger
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: snapprice <command> [flags]\n\ncommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nrun 'snapprice <command> -h' for command flags\n")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	name := os.Args[1]
+	if name == "-h" || name == "--help" || name == "help" {
+		usage()
+		return
+	}
+
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == name {
+			cmd = &commands[i]
+			break
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := cmd.run(ctx, os.Args[2:]); err != nil && !errors.Is(err, context.Canceled) {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		fmt.Fprintf(os.Stderr, "snapprice %s: %v\n", name, err)
+		stop()
+		os.Exit(1)
+	}
+}
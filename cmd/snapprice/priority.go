@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/priority"
+	"github.com/mindsgn-studio/takealot-scraper/internal/recrawl"
+)
+
+// priorityFlags pick how keywords and items are ordered, which of them are
+// due, and how many of them each pass visits.
+type priorityFlags struct {
+	enabled         bool
+	keywordsPerPass int
+	itemsPerPass    int
+	adaptive        bool
+	recrawlMin      time.Duration
+	recrawlMax      time.Duration
+}
+
+func (p *priorityFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&p.enabled, "priority", true, "visit watched, volatile and stale keywords and items first; false keeps a random order")
+	fs.BoolVar(&p.adaptive, "adaptive", true, "skip keywords and items until their learned recrawl interval has passed; false visits all of them every pass")
+	fs.DurationVar(&p.recrawlMin, "recrawl-min", recrawl.DefaultMin, "shortest learned recrawl interval, for prices that keep changing")
+	fs.DurationVar(&p.recrawlMax, "recrawl-max", recrawl.DefaultMax, "longest learned recrawl interval, for prices that never change")
+}
+
+func (p *priorityFlags) registerKeywords(fs *flag.FlagSet) {
+	fs.IntVar(&p.keywordsPerPass, "keywords-per-pass", 0, "crawl only this many of the highest-priority keywords per pass (default all)")
+}
+
+func (p *priorityFlags) registerItems(fs *flag.FlagSet) {
+	fs.IntVar(&p.itemsPerPass, "items-per-pass", 0, "refresh only this many of the highest-priority items per pass (default all)")
+}
+
+func (p *priorityFlags) weights() priority.Weights {
+	if !p.enabled {
+		return priority.Weights{}
+	}
+	return priority.DefaultWeights()
+}
+
+func (p *priorityFlags) recrawl() (recrawl.Policy, error) {
+	if !p.adaptive {
+		return recrawl.Policy{}, nil
+	}
+	if p.recrawlMin <= 0 || p.recrawlMax < p.recrawlMin {
+		return recrawl.Policy{}, fmt.Errorf("--recrawl-min must be positive and no more than --recrawl-max")
+	}
+	policy := recrawl.DefaultPolicy()
+	policy.Min = p.recrawlMin
+	policy.Max = p.recrawlMax
+	return policy, nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/archive"
+	"github.com/mindsgn-studio/takealot-scraper/internal/health"
+	"github.com/mindsgn-studio/takealot-scraper/internal/httpx"
+	"github.com/mindsgn-studio/takealot-scraper/internal/scraper"
+	"github.com/mindsgn-studio/takealot-scraper/internal/source/takealot"
+	"github.com/mindsgn-studio/takealot-scraper/internal/store"
+	"golang.org/x/time/rate"
+)
+
+const dateLayout = "2006-01-02"
+
+func runReprocess(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("reprocess", flag.ContinueOnError)
+	var g globalFlags
+	g.register(fs)
+	sourceName := fs.String("source", takealot.Name, "retailer whose archived pages to reprocess: takealot, amazon or shoprite")
+	storeKind := fs.String("store", store.KindMongo, "store to write to: mongo, postgres or memory")
+	from := fs.String("from", "", "first capture date to include, YYYY-MM-DD (default the oldest)")
+	to := fs.String("to", "", "last capture date to include, YYYY-MM-DD (default the newest)")
+	var af archiveFlags
+	af.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := g.load()
+	if err != nil {
+		return err
+	}
+
+	var filter archive.Filter
+	if *from != "" {
+		if filter.From, err = time.Parse(dateLayout, *from); err != nil {
+			return fmt.Errorf("--from: %w", err)
+		}
+	}
+	if *to != "" {
+		day, err := time.Parse(dateLayout, *to)
+		if err != nil {
+			return fmt.Errorf("--to: %w", err)
+		}
+		filter.To = day.AddDate(0, 0, 1)
+	}
+
+	logger := newLogger(cfg, "reprocess").With("source", *sourceName)
+	arc, err := af.open(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	if arc == nil {
+		return errors.New("--archive-dir or --archive-gridfs is required")
+	}
+	defer closeArchive(logger, arc)
+
+	// Sources only parse here; nothing is fetched.
+	hopts := httpOptions{limiter: httpx.NewHostLimiter(rate.Inf, 1), logger: logger}
+	source, opts, err := newSource(*sourceName, cfg, &hopts)
+	if err != nil {
+		return err
+	}
+
+	st, err := openStore(ctx, *storeKind, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(logger, st)
+
+	engine := scraper.NewEngine(cfg, source, opts, st, logger)
+	var ready health.Checker
+	ready.Add("store", st.Ping)
+	ready.Add("progress", health.Stalled(engine.LastProgress, g.stallAfter))
+	srv, err := g.serve(ctx, logger, &ready)
+	if err != nil {
+		return err
+	}
+	if srv != nil {
+		defer srv.Close()
+	}
+
+	stats, err := engine.Reprocess(ctx, arc, filter)
+	logger.Info("reprocess finished", "pages", stats.Pages, "failed", stats.Failed, "listings", stats.Listings, "skipped", stats.Skipped)
+	return err
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/health"
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"github.com/mindsgn-studio/takealot-scraper/internal/store"
+)
+
+const defaultRunsLimit = 20
+
+func runRuns(ctx context.Context, args []string) error {
+	if len(args) == 0 || (args[0] != "list" && args[0] != "compare") {
+		return errors.New("usage: snapprice runs list|compare [flags] [run-id run-id]")
+	}
+	action := args[0]
+
+	fs := flag.NewFlagSet("runs "+action, flag.ContinueOnError)
+	var g globalFlags
+	g.register(fs)
+	sourceName := fs.String("source", "", "only show runs of this retailer (default every retailer)")
+	storeKind := fs.String("store", store.KindMongo, "store holding the run ledger: mongo or memory")
+	limit := fs.Int("limit", defaultRunsLimit, "number of recent runs to list")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	cfg, err := g.load()
+	if err != nil {
+		return err
+	}
+
+	logger := newLogger(cfg, "runs")
+	st, err := openStore(ctx, *storeKind, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(logger, st)
+
+	var ready health.Checker
+	ready.Add("store", st.Ping)
+	srv, err := g.serve(ctx, logger, &ready)
+	if err != nil {
+		return err
+	}
+	if srv != nil {
+		defer srv.Close()
+	}
+
+	ledger, ok := st.(store.Ledger)
+	if !ok {
+		return fmt.Errorf("%s store does not keep a run ledger", *storeKind)
+	}
+
+	if action == "compare" {
+		a, b, err := runsToCompare(ctx, ledger, *sourceName, fs.Args())
+		if err != nil {
+			return err
+		}
+		return printRunComparison(os.Stdout, a, b)
+	}
+
+	runs, err := ledger.ListRuns(ctx, *sourceName, *limit)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RUN\tSOURCE\tSTARTED\tDURATION\tEXIT\tKEYWORDS\tPAGES\tNEW\tUPDATED\tPRICES\tERRORS")
+	for _, run := range runs {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%d\t%d\t%d\t%d\t%s\n",
+			run.ID, run.Source, run.Started.Format(time.RFC3339), runDuration(run), run.Exit,
+			run.Keywords, run.Pages, run.ItemsNew, run.ItemsUpdated, run.Prices, formatRunErrors(run.Errors))
+	}
+	return w.Flush()
+}
+
+// runsToCompare loads the two named runs, or the two most recent runs of
+// source, older first.
+func runsToCompare(ctx context.Context, ledger store.Ledger, source string, ids []string) (model.Run, model.Run, error) {
+	switch len(ids) {
+	case 2:
+		a, err := ledger.Run(ctx, ids[0])
+		if err != nil {
+			return model.Run{}, model.Run{}, fmt.Errorf("run %s: %w", ids[0], err)
+		}
+		b, err := ledger.Run(ctx, ids[1])
+		if err != nil {
+			return model.Run{}, model.Run{}, fmt.Errorf("run %s: %w", ids[1], err)
+		}
+		return a, b, nil
+	case 0:
+		if source == "" {
+			return model.Run{}, model.Run{}, errors.New("--source is required when no run IDs are given")
+		}
+		runs, err := ledger.ListRuns(ctx, source, 2)
+		if err != nil {
+			return model.Run{}, model.Run{}, err
+		}
+		if len(runs) < 2 {
+			return model.Run{}, model.Run{}, fmt.Errorf("%s has fewer than two runs", source)
+		}
+		return runs[1], runs[0], nil
+	default:
+		return model.Run{}, model.Run{}, errors.New("compare takes two run IDs or none")
+	}
+}
+
+func printRunComparison(out io.Writer, a, b model.Run) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "\t%s\t%s\tCHANGE\n", a.ID, b.ID)
+	fmt.Fprintf(w, "source\t%s\t%s\t\n", a.Source, b.Source)
+	fmt.Fprintf(w, "started\t%s\t%s\t\n", a.Started.Format(time.RFC3339), b.Started.Format(time.RFC3339))
+	fmt.Fprintf(w, "duration\t%s\t%s\t\n", runDuration(a), runDuration(b))
+	fmt.Fprintf(w, "exit\t%s\t%s\t\n", a.Exit, b.Exit)
+
+	rows := []struct {
+		name string
+		a, b int64
+	}{
+		{"keywords", a.Keywords, b.Keywords},
+		{"pages", a.Pages, b.Pages},
+		{"items new", a.ItemsNew, b.ItemsNew},
+		{"items updated", a.ItemsUpdated, b.ItemsUpdated},
+		{"items total", a.ItemsNew + a.ItemsUpdated, b.ItemsNew + b.ItemsUpdated},
+		{"prices", a.Prices, b.Prices},
+		{"errors", sumRunErrors(a.Errors), sumRunErrors(b.Errors)},
+	}
+	for _, row := range rows {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", row.name, row.a, row.b, percentChange(row.a, row.b))
+	}
+	return w.Flush()
+}
+
+func runDuration(run model.Run) string {
+	if run.Finished.IsZero() {
+		return "-"
+	}
+	return run.Finished.Sub(run.Started).Round(time.Second).String()
+}
+
+func formatRunErrors(errs map[string]int64) string {
+	if len(errs) == 0 {
+		return "-"
+	}
+	categories := make([]string, 0, len(errs))
+	for category := range errs {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	parts := make([]string, len(categories))
+	for i, category := range categories {
+		parts[i] = fmt.Sprintf("%s=%d", category, errs[category])
+	}
+	return strings.Join(parts, ",")
+}
+
+func sumRunErrors(errs map[string]int64) int64 {
+	var n int64
+	for _, count := range errs {
+		n += count
+	}
+	return n
+}
+
+func percentChange(a, b int64) string {
+	if a == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%+.1f%%", float64(b-a)/float64(a)*100)
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/archive"
+	"github.com/mindsgn-studio/takealot-scraper/internal/health"
+	"github.com/mindsgn-studio/takealot-scraper/internal/httpx"
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"github.com/mindsgn-studio/takealot-scraper/internal/scraper"
+	"github.com/mindsgn-studio/takealot-scraper/internal/source/amazon"
+	"github.com/mindsgn-studio/takealot-scraper/internal/source/shoprite"
+	"github.com/mindsgn-studio/takealot-scraper/internal/source/takealot"
+	"github.com/mindsgn-studio/takealot-scraper/internal/store"
+	"golang.org/x/time/rate"
+)
+
+// httpOptions decide how sources reach the network.
+type httpOptions struct {
+	limiter *httpx.HostLimiter
+	// rps overrides the source's default request budget when positive.
+	rps       float64
+	recordDir string
+	replayDir string
+	policy    httpx.Policy
+	logger    *slog.Logger
+
+	transports []*httpx.Transport
+}
+
+func (o *httpOptions) register(fs *flag.FlagSet) {
+	fs.Float64Var(&o.rps, "rate", 0, "requests per second to the source's host (default the source's configured budget)")
+	fs.StringVar(&o.recordDir, "record", "", "save every HTTP response to this fixture directory")
+	fs.StringVar(&o.replayDir, "replay", "", "serve HTTP responses from this fixture directory instead of the network")
+}
+
+// setup checks the flags and prepares the limiter and retry policy every
+// transport shares.
+func (o *httpOptions) setup(cfg model.Config, logger *slog.Logger) error {
+	if o.recordDir != "" && o.replayDir != "" {
+		return fmt.Errorf("--record and --replay cannot be used together")
+	}
+	o.limiter = httpx.NewHostLimiter(rate.Inf, 1)
+	o.policy = httpx.ConfiguredPolicy(cfg.HTTP)
+	o.logger = logger
+	return nil
+}
+
+// transport returns the round tripper for a source talking to host: replayed
+// fixtures, or the network throttled by the shared limiter and retried by
+// the shared HTTP layer, optionally recording as it goes. sc supplies the
+// default request budget and the timeout of each attempt.
+func (o *httpOptions) transport(host string, sc model.SourceConfig) http.RoundTripper {
+	if o.replayDir != "" {
+		return httpx.Replay(o.replayDir)
+	}
+
+	var base http.RoundTripper
+	if o.recordDir != "" {
+		base = httpx.Record(o.recordDir, nil)
+	}
+	rps := o.rps
+	if rps <= 0 {
+		rps = sc.RequestsPerSecond
+	}
+	o.limiter.SetLimit(host, rate.Limit(rps), 1)
+	t := httpx.NewTransport(o.limiter.Transport(base), o.policy, o.logger)
+	t.SetAttemptTimeout(sc.Timeout)
+	o.transports = append(o.transports, t)
+	return t
+}
+
+// openCircuits lists the hosts refusing requests across every transport
+// handed out.
+func (o *httpOptions) openCircuits() []string {
+	var hosts []string
+	for _, t := range o.transports {
+		hosts = append(hosts, t.OpenCircuits()...)
+	}
+	return hosts
+}
+
+// sourceDefaults are the built-in settings of every retailer newSource
+// knows, which the config file can override.
+func sourceDefaults() map[string]model.SourceConfig {
+	return map[string]model.SourceConfig{
+		takealot.Name: takealot.Defaults(),
+		amazon.Name:   amazon.Defaults(),
+		shoprite.Name: shoprite.Defaults(),
+	}
+}
+
+func newSource(name string, cfg model.Config, hopts *httpOptions) (scraper.Source, scraper.Options, error) {
+	sc := cfg.Source(name)
+	switch name {
+	case takealot.Name:
+		transport := hopts.transport(takealot.Host, sc)
+		return takealot.New(sc, transport), scraper.Options{PriceDedupWindow: sc.PriceDedupWindow, Host: takealot.Host}, nil
+	case amazon.Name:
+		transport := hopts.transport(amazon.Host, sc)
+		return amazon.New(sc, transport), scraper.Options{PriceDedupWindow: sc.PriceDedupWindow, Host: amazon.Host}, nil
+	case shoprite.Name:
+		transport := hopts.transport(shoprite.Host, sc)
+		return shoprite.New(sc, transport), scraper.Options{PriceDedupWindow: sc.PriceDedupWindow, Host: shoprite.Host}, nil
+	default:
+		return nil, scraper.Options{}, fmt.Errorf("unknown source %q (want takealot, amazon or shoprite)", name)
+	}
+}
+
+// engineFlags tune the scrape engine; they are shared by scrape and daemon.
+type engineFlags struct {
+	workerID         string
+	leaseTTL         time.Duration
+	leaseCooldown    time.Duration
+	workers          int
+	driftThreshold   int
+	archiveRetention time.Duration
+	http             httpOptions
+	archive          archiveFlags
+}
+
+func (f *engineFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.workerID, "worker-id", scraper.DefaultWorkerID(), "name of this process in keyword leases")
+	fs.DurationVar(&f.leaseTTL, "lease-ttl", scraper.DefaultLeaseTTL, "keyword lease lifetime between heartbeats; 0 disables leasing")
+	fs.DurationVar(&f.leaseCooldown, "lease-cooldown", scraper.DefaultLeaseCooldown, "how long a finished keyword is left alone by all workers")
+	fs.IntVar(&f.workers, "workers", scraper.DefaultWorkers, "number of keywords scraped in parallel")
+	fs.IntVar(&f.driftThreshold, "drift-threshold", scraper.DefaultDriftThreshold, "drifted pages in a row before the source is reported degraded; 0 disables")
+	f.http.register(fs)
+	f.archive.register(fs)
+	fs.DurationVar(&f.archiveRetention, "archive-retention", archive.DefaultRetention, "archived pages older than this are pruned at the start of each pass; 0 keeps them")
+}
+
+// setup checks the flags and prepares the shared HTTP layer.
+func (f *engineFlags) setup(cfg model.Config, logger *slog.Logger) error {
+	if f.workers < 1 {
+		return fmt.Errorf("--workers must be at least 1")
+	}
+	return f.http.setup(cfg, logger)
+}
+
+func (f *engineFlags) apply(opts *scraper.Options) {
+	opts.DriftThreshold = f.driftThreshold
+	opts.Workers = f.workers
+	opts.WorkerID = f.workerID
+	opts.LeaseTTL = f.leaseTTL
+	opts.LeaseCooldown = f.leaseCooldown
+	opts.ArchiveRetention = f.archiveRetention
+}
+
+func runScrape(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("scrape", flag.ContinueOnError)
+	var g globalFlags
+	g.register(fs)
+	sourceName := fs.String("source", takealot.Name, "retailer to crawl: takealot, amazon or shoprite")
+	storeKind := fs.String("store", store.KindMongo, "store to write to: mongo, postgres or memory")
+	var ef engineFlags
+	ef.register(fs)
+	var pf priorityFlags
+	pf.register(fs)
+	pf.registerKeywords(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := g.load()
+	if err != nil {
+		return err
+	}
+	logger := newLogger(cfg, "scrape").With("source", *sourceName)
+	if err := ef.setup(cfg, logger); err != nil {
+		return err
+	}
+	source, opts, err := newSource(*sourceName, cfg, &ef.http)
+	if err != nil {
+		return err
+	}
+	ef.apply(&opts)
+	opts.Priority = pf.weights()
+	opts.KeywordsPerPass = pf.keywordsPerPass
+	if opts.Recrawl, err = pf.recrawl(); err != nil {
+		return err
+	}
+
+	arc, err := ef.archive.open(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	if arc != nil {
+		defer closeArchive(logger, arc)
+		opts.Archive = arc
+	}
+
+	st, err := openStore(ctx, *storeKind, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(logger, st)
+
+	engine := scraper.NewEngine(cfg, source, opts, st, logger)
+	var ready health.Checker
+	ready.Add("store", st.Ping)
+	ready.Add("circuits", health.Circuits(ef.http.openCircuits))
+	ready.Add("progress", health.Stalled(engine.LastProgress, g.stallAfter))
+	ready.Add("drift", health.Degraded(engine.Degraded))
+	srv, err := g.serve(ctx, logger, &ready)
+	if err != nil {
+		return err
+	}
+	if srv != nil {
+		defer srv.Close()
+	}
+
+	err = engine.Run(ctx)
+	drift := engine.Drift()
+	logger.Info("scraper finished", "drifted_pages", drift.Pages, "skipped_products", drift.Products, "degraded", drift.Degraded)
+	return err
+}
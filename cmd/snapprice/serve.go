@@ -0,0 +1,25 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/health"
+	"github.com/mindsgn-studio/takealot-scraper/internal/metrics"
+)
+
+// serve starts the HTTP listener when --listen is set, serving /metrics,
+// /healthz, and /readyz answered by ready. The caller must Close the
+// returned server, which is nil when the listener is off.
+func (g *globalFlags) serve(ctx context.Context, logger *slog.Logger, ready *health.Checker) (*metrics.Server, error) {
+	if g.listen == "" {
+		return nil, nil
+	}
+	srv, err := metrics.Listen(ctx, g.listen, logger)
+	if err != nil {
+		return nil, err
+	}
+	srv.Mux.HandleFunc("/healthz", health.Live)
+	srv.Mux.Handle("/readyz", ready)
+	return srv, nil
+}
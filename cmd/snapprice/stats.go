@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/health"
+	"github.com/mindsgn-studio/takealot-scraper/internal/store"
+)
+
+func runStats(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	var g globalFlags
+	g.register(fs)
+	storeKind := fs.String("store", store.KindMongo, "store to report on: mongo, postgres or memory")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := g.load()
+	if err != nil {
+		return err
+	}
+
+	logger := newLogger(cfg, "stats")
+	st, err := openStore(ctx, *storeKind, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(logger, st)
+
+	var ready health.Checker
+	ready.Add("store", st.Ping)
+	srv, err := g.serve(ctx, logger, &ready)
+	if err != nil {
+		return err
+	}
+	if srv != nil {
+		defer srv.Close()
+	}
+
+	stats, err := st.Stats(ctx)
+	if err != nil {
+		return err
+	}
+
+	sources := make([]string, 0, len(stats.ItemsBySource))
+	for source := range stats.ItemsBySource {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SOURCE\tITEMS")
+	for _, source := range sources {
+		fmt.Fprintf(w, "%s\t%d\n", source, stats.ItemsBySource[source])
+	}
+	fmt.Fprintf(w, "\nprices\t%d\n", stats.Prices)
+	if !stats.LatestPrice.IsZero() {
+		fmt.Fprintf(w, "latest price\t%s\n", stats.LatestPrice.Format(time.RFC3339))
+	}
+	return w.Flush()
+}
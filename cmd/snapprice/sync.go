@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"flag"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/health"
+	"github.com/mindsgn-studio/takealot-scraper/internal/migrate"
+	"github.com/mindsgn-studio/takealot-scraper/internal/store"
+)
+
+func runSync(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("sync", flag.ContinueOnError)
+	var g globalFlags
+	g.register(fs)
+	from := fs.String("from", store.KindMongo, "store to copy from")
+	to := fs.String("to", store.KindPostgres, "store to copy to")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := g.load()
+	if err != nil {
+		return err
+	}
+
+	logger := newLogger(cfg, "sync")
+	src, err := openStore(ctx, *from, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(logger, src)
+
+	dst, err := openStore(ctx, *to, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(logger, dst)
+
+	var ready health.Checker
+	ready.Add("from", src.Ping)
+	ready.Add("to", dst.Ping)
+	srv, err := g.serve(ctx, logger, &ready)
+	if err != nil {
+		return err
+	}
+	if srv != nil {
+		defer srv.Close()
+	}
+
+	migrate.New(cfg, src, dst, logger).Run(ctx)
+	logger.Info("sync completed")
+	return ctx.Err()
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/health"
+	"github.com/mindsgn-studio/takealot-scraper/internal/store"
+	"github.com/mindsgn-studio/takealot-scraper/internal/watch"
+)
+
+func watcherChecks(st store.Store, w *watch.Watcher, stallAfter time.Duration) *health.Checker {
+	var ready health.Checker
+	ready.Add("store", st.Ping)
+	ready.Add("circuits", health.Circuits(w.OpenCircuits))
+	ready.Add("progress", health.Stalled(w.LastProgress, stallAfter))
+	return &ready
+}
+
+func runWatch(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	var g globalFlags
+	g.register(fs)
+	storeKind := fs.String("store", store.KindPostgres, "store holding watches, items and prices: mongo, postgres or memory")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := g.load()
+	if err != nil {
+		return err
+	}
+
+	logger := newLogger(cfg, "watch")
+	st, err := openStore(ctx, *storeKind, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(logger, st)
+
+	w := watch.New(cfg, st, watch.Options{}, logger)
+	srv, err := g.serve(ctx, logger, watcherChecks(st, w, g.stallAfter))
+	if err != nil {
+		return err
+	}
+	if srv != nil {
+		defer srv.Close()
+	}
+
+	return w.Watch(ctx)
+}
+
+func runRefresh(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("refresh", flag.ContinueOnError)
+	var g globalFlags
+	g.register(fs)
+	storeKind := fs.String("store", store.KindMongo, "store holding items and prices: mongo, postgres or memory")
+	var pf priorityFlags
+	pf.register(fs)
+	pf.registerItems(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := g.load()
+	if err != nil {
+		return err
+	}
+
+	policy, err := pf.recrawl()
+	if err != nil {
+		return err
+	}
+
+	logger := newLogger(cfg, "refresh")
+	st, err := openStore(ctx, *storeKind, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(logger, st)
+
+	w := watch.New(cfg, st, watch.Options{Priority: pf.weights(), ItemsPerPass: pf.itemsPerPass, Recrawl: policy}, logger)
+	srv, err := g.serve(ctx, logger, watcherChecks(st, w, g.stallAfter))
+	if err != nil {
+		return err
+	}
+	if srv != nil {
+		defer srv.Close()
+	}
+
+	return w.Refresh(ctx)
+}
@@ -0,0 +1,112 @@
+// Package archive keeps the raw pages fetched by the scrapers, gzipped, so
+// extraction can be checked and re-run later.
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DefaultRetention = 30 * 24 * time.Hour
+
+// Entry identifies one archived page.
+type Entry struct {
+	Source   string
+	Keyword  string
+	Page     int
+	Captured time.Time
+}
+
+// Filter selects archived pages. Zero From or To leaves that end open.
+type Filter struct {
+	Source string
+	From   time.Time
+	To     time.Time
+}
+
+func (f Filter) match(e Entry) bool {
+	if f.Source != "" && e.Source != f.Source {
+		return false
+	}
+	if !f.From.IsZero() && e.Captured.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !e.Captured.Before(f.To) {
+		return false
+	}
+	return true
+}
+
+// Archiver stores raw pages and prunes old ones.
+type Archiver interface {
+	Put(ctx context.Context, entry Entry, raw []byte) error
+	// Each calls fn with every page matching f, decompressed, oldest
+	// capture first.
+	Each(ctx context.Context, f Filter, fn func(Entry, []byte) error) error
+	// Prune removes pages captured before cutoff and reports how many
+	// were removed.
+	Prune(ctx context.Context, cutoff time.Time) (int, error)
+	Close(ctx context.Context) error
+}
+
+const timeLayout = "20060102T150405.000000000Z"
+
+// key is the entry's path-like name: source/keyword/capture-time-pPAGE.gz.
+// The keyword is path-escaped so it is a single segment.
+func (e Entry) key() string {
+	return fmt.Sprintf("%s/%s/%s-p%04d.gz", e.Source, url.PathEscape(e.Keyword), e.Captured.UTC().Format(timeLayout), e.Page)
+}
+
+// parseKey is the inverse of Entry.key.
+func parseKey(key string) (Entry, error) {
+	parts := strings.Split(key, "/")
+	if len(parts) != 3 {
+		return Entry{}, fmt.Errorf("archive key %q: want source/keyword/file", key)
+	}
+	keyword, err := url.PathUnescape(parts[1])
+	if err != nil {
+		return Entry{}, fmt.Errorf("archive key %q: %w", key, err)
+	}
+	name := strings.TrimSuffix(parts[2], ".gz")
+	at, page, ok := strings.Cut(name, "-p")
+	if !ok {
+		return Entry{}, fmt.Errorf("archive key %q: no page number", key)
+	}
+	captured, err := time.Parse(timeLayout, at)
+	if err != nil {
+		return Entry{}, fmt.Errorf("archive key %q: %w", key, err)
+	}
+	n, err := strconv.Atoi(page)
+	if err != nil {
+		return Entry{}, fmt.Errorf("archive key %q: %w", key, err)
+	}
+	return Entry{Source: parts[0], Keyword: keyword, Page: n, Captured: captured}, nil
+}
+
+func decompress(r io.Reader) ([]byte, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+func compress(raw []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(raw); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,137 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Dir archives pages as files under a local directory.
+type Dir struct {
+	root string
+}
+
+func NewDir(root string) (*Dir, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("create archive dir: %w", err)
+	}
+	return &Dir{root: root}, nil
+}
+
+func (d *Dir) Put(_ context.Context, entry Entry, raw []byte) error {
+	data, err := compress(raw)
+	if err != nil {
+		return fmt.Errorf("compress page: %w", err)
+	}
+	path := filepath.Join(d.root, filepath.FromSlash(entry.key()))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create archive dir: %w", err)
+	}
+	// Write then rename so a crash never leaves a truncated archive.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("write archive: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+func (d *Dir) Each(ctx context.Context, f Filter, fn func(Entry, []byte) error) error {
+	type found struct {
+		path  string
+		entry Entry
+	}
+	var pages []found
+
+	root := d.root
+	if f.Source != "" {
+		root = filepath.Join(d.root, f.Source)
+	}
+	err := filepath.WalkDir(root, func(path string, de fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && path == root {
+			return fs.SkipAll
+		}
+		if err != nil || de.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(d.root, path)
+		if err != nil {
+			return err
+		}
+		entry, err := parseKey(filepath.ToSlash(rel))
+		if err == nil && f.match(entry) {
+			pages = append(pages, found{path: path, entry: entry})
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("list archive: %w", err)
+	}
+	sort.Slice(pages, func(i, j int) bool { return pages[i].entry.Captured.Before(pages[j].entry.Captured) })
+
+	for _, page := range pages {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		raw, err := readGzipFile(page.path)
+		if err != nil {
+			return fmt.Errorf("read archive %s: %w", page.path, err)
+		}
+		if err := fn(page.entry, raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readGzipFile(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return decompress(file)
+}
+
+func (d *Dir) Prune(ctx context.Context, cutoff time.Time) (int, error) {
+	removed := 0
+	err := filepath.WalkDir(d.root, func(path string, de fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if de.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(d.root, path)
+		if err != nil {
+			return err
+		}
+		entry, err := parseKey(filepath.ToSlash(rel))
+		if err != nil {
+			// Not ours; leave it alone.
+			return nil
+		}
+		if entry.Captured.Before(cutoff) {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+			removed++
+		}
+		return nil
+	})
+	if err != nil {
+		return removed, fmt.Errorf("prune archive: %w", err)
+	}
+	return removed, nil
+}
+
+func (d *Dir) Close(context.Context) error {
+	return nil
+}
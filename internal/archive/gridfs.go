@@ -0,0 +1,233 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/database"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	DefaultBucket = "archive"
+	// DefaultTimeout bounds a page's upload or download when NewGridFS is
+	// given no timeout.
+	DefaultTimeout = 30 * time.Second
+)
+
+// GridFS archives pages in a Mongo GridFS bucket. Each file's metadata
+// holds the entry fields so pages can be found without parsing names.
+type GridFS struct {
+	client  *mongo.Client
+	bucket  *gridfs.Bucket
+	timeout time.Duration
+}
+
+// NewGridFS opens the archive bucket in dbName. timeout bounds each page's
+// upload and download, as the scrape's own context usually has no
+// deadline; zero uses DefaultTimeout.
+func NewGridFS(ctx context.Context, uri, dbName string, timeout time.Duration) (*GridFS, error) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	client, err := database.ConnectMongo(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	bucket, err := gridfs.NewBucket(client.Database(dbName), options.GridFSBucket().SetName(DefaultBucket))
+	if err != nil {
+		_ = client.Disconnect(ctx)
+		return nil, fmt.Errorf("open gridfs bucket: %w", err)
+	}
+	// Each filters by source and capture time and Prune by capture time.
+	_, err = bucket.GetFilesCollection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "metadata.source", Value: 1}, {Key: "metadata.captured", Value: 1}}},
+		{Keys: bson.D{{Key: "metadata.captured", Value: 1}}},
+	})
+	if err != nil {
+		_ = client.Disconnect(ctx)
+		return nil, fmt.Errorf("ensure archive indexes: %w", err)
+	}
+	return &GridFS{client: client, bucket: bucket, timeout: timeout}, nil
+}
+
+type gridfsMetadata struct {
+	Source   string    `bson:"source"`
+	Keyword  string    `bson:"keyword"`
+	Page     int       `bson:"page"`
+	Captured time.Time `bson:"captured"`
+}
+
+// Put uploads the page in chunks, giving up when ctx is done or the
+// archive's timeout passes. The driver's upload takes no context, so the
+// deadline becomes the stream's write deadline and cancellation is checked
+// between chunks.
+func (g *GridFS) Put(ctx context.Context, entry Entry, raw []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, g.timeout)
+	defer cancel()
+
+	data, err := compress(raw)
+	if err != nil {
+		return fmt.Errorf("compress page: %w", err)
+	}
+	opts := options.GridFSUpload().SetMetadata(gridfsMetadata{
+		Source:   entry.Source,
+		Keyword:  entry.Keyword,
+		Page:     entry.Page,
+		Captured: entry.Captured.UTC(),
+	})
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("upload archive: %w", err)
+	}
+	stream, err := g.bucket.OpenUploadStream(entry.key(), opts)
+	if err != nil {
+		return fmt.Errorf("upload archive: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := stream.SetWriteDeadline(deadline); err != nil {
+			_ = stream.Abort()
+			return fmt.Errorf("upload archive: %w", err)
+		}
+	}
+	for len(data) > 0 {
+		if err := ctx.Err(); err != nil {
+			_ = stream.Abort()
+			return fmt.Errorf("upload archive: %w", err)
+		}
+		n := min(len(data), uploadChunk)
+		if _, err := stream.Write(data[:n]); err != nil {
+			_ = stream.Abort()
+			return fmt.Errorf("upload archive: %w", err)
+		}
+		data = data[n:]
+	}
+	if err := stream.Close(); err != nil {
+		return fmt.Errorf("upload archive: %w", err)
+	}
+	return nil
+}
+
+// uploadChunk is how much of a page Put writes between checks of its
+// context; it matches GridFS's default chunk size.
+const uploadChunk = 255 << 10
+
+func (g *GridFS) Each(ctx context.Context, f Filter, fn func(Entry, []byte) error) error {
+	filter := bson.M{}
+	if f.Source != "" {
+		filter["metadata.source"] = f.Source
+	}
+	captured := bson.M{}
+	if !f.From.IsZero() {
+		captured["$gte"] = f.From.UTC()
+	}
+	if !f.To.IsZero() {
+		captured["$lt"] = f.To.UTC()
+	}
+	if len(captured) > 0 {
+		filter["metadata.captured"] = captured
+	}
+
+	opts := options.GridFSFind().SetSort(bson.D{{Key: "metadata.captured", Value: 1}})
+	cursor, err := g.bucket.FindContext(ctx, filter, opts)
+	if err != nil {
+		return fmt.Errorf("find archives: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var file struct {
+			ID       primitive.ObjectID `bson:"_id"`
+			Length   int64              `bson:"length"`
+			Metadata gridfsMetadata     `bson:"metadata"`
+		}
+		if err := cursor.Decode(&file); err != nil {
+			return fmt.Errorf("decode archive file: %w", err)
+		}
+
+		data, err := g.download(ctx, file.ID, file.Length)
+		if err != nil {
+			return fmt.Errorf("download archive %s: %w", file.ID.Hex(), err)
+		}
+		raw, err := decompress(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("decompress archive %s: %w", file.ID.Hex(), err)
+		}
+
+		entry := Entry{
+			Source:   file.Metadata.Source,
+			Keyword:  file.Metadata.Keyword,
+			Page:     file.Metadata.Page,
+			Captured: file.Metadata.Captured,
+		}
+		if err := fn(entry, raw); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// download reads a file's chunks. The driver's downloads take no context,
+// so the chunks collection is read directly, bounded by ctx and the
+// archive's timeout.
+func (g *GridFS) download(ctx context.Context, id primitive.ObjectID, length int64) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, g.timeout)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "n", Value: 1}})
+	cursor, err := g.bucket.GetChunksCollection().Find(ctx, bson.M{"files_id": id}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	data := make([]byte, 0, length)
+	for cursor.Next(ctx) {
+		var chunk struct {
+			Data []byte `bson:"data"`
+		}
+		if err := cursor.Decode(&chunk); err != nil {
+			return nil, fmt.Errorf("decode chunk: %w", err)
+		}
+		data = append(data, chunk.Data...)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	if int64(len(data)) != length {
+		return nil, fmt.Errorf("read %d of %d bytes", len(data), length)
+	}
+	return data, nil
+}
+
+func (g *GridFS) Prune(ctx context.Context, cutoff time.Time) (int, error) {
+	cursor, err := g.bucket.FindContext(ctx, bson.M{"metadata.captured": bson.M{"$lt": cutoff.UTC()}})
+	if err != nil {
+		return 0, fmt.Errorf("find old archives: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	removed := 0
+	for cursor.Next(ctx) {
+		var file struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if err := cursor.Decode(&file); err != nil {
+			return removed, fmt.Errorf("decode archive file: %w", err)
+		}
+		if err := g.bucket.DeleteContext(ctx, file.ID); err != nil {
+			return removed, fmt.Errorf("delete archive %s: %w", file.ID.Hex(), err)
+		}
+		removed++
+	}
+	return removed, cursor.Err()
+}
+
+func (g *GridFS) Close(ctx context.Context) error {
+	return g.client.Disconnect(ctx)
+}
@@ -0,0 +1,177 @@
+// Package catalogue reads and writes keyword catalogue files. The seed
+// format is the one brand.txt has always used: comma-separated keywords,
+// one category group per line, with each line optionally naming its
+// category before a colon. JSON carries every field, stats included.
+package catalogue
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+)
+
+const (
+	FormatSeed = "seed"
+	FormatJSON = "json"
+)
+
+// ParseSeed reads keywords in the seed format. Lines without a category of
+// their own get category. Every keyword is enabled; repeats after the first
+// are dropped, ignoring case.
+func ParseSeed(r io.Reader, category string) ([]model.Keyword, error) {
+	var keywords []model.Keyword
+	seen := make(map[string]struct{})
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		lineCategory := category
+		if name, rest, ok := strings.Cut(line, ":"); ok && !strings.Contains(name, ",") {
+			lineCategory = strings.TrimSpace(name)
+			line = rest
+		}
+		for _, raw := range strings.Split(line, ",") {
+			keyword := strings.TrimSpace(raw)
+			if keyword == "" || keyword == "." {
+				continue
+			}
+			key := strings.ToLower(keyword)
+			if _, dup := seen[key]; dup {
+				continue
+			}
+			seen[key] = struct{}{}
+			keywords = append(keywords, model.Keyword{Keyword: keyword, Category: lineCategory, Enabled: true})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read seed: %w", err)
+	}
+	return keywords, nil
+}
+
+// WriteSeed writes keywords in the seed format, one line per category in
+// the order categories first appear.
+func WriteSeed(w io.Writer, keywords []model.Keyword) error {
+	var order []string
+	groups := make(map[string][]string)
+	for _, k := range keywords {
+		if _, ok := groups[k.Category]; !ok {
+			order = append(order, k.Category)
+		}
+		groups[k.Category] = append(groups[k.Category], k.Keyword)
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, category := range order {
+		if category != "" {
+			fmt.Fprintf(bw, "%s: ", category)
+		}
+		fmt.Fprintf(bw, "%s,\n", strings.Join(groups[category], ", "))
+	}
+	return bw.Flush()
+}
+
+type jsonStats struct {
+	LastCrawled time.Time `json:"last_crawled"`
+	Crawls      int64     `json:"crawls"`
+	Pages       int64     `json:"pages"`
+	Listings    int64     `json:"listings"`
+	NewItems    int64     `json:"new_items"`
+}
+
+type jsonKeyword struct {
+	Keyword  string               `json:"keyword"`
+	Category string               `json:"category,omitempty"`
+	Enabled  bool                 `json:"enabled"`
+	Sources  []string             `json:"sources,omitempty"`
+	AddedBy  string               `json:"added_by,omitempty"`
+	Added    time.Time            `json:"added"`
+	Stats    map[string]jsonStats `json:"stats,omitempty"`
+}
+
+// ReadJSON reads a JSON array of keywords as written by WriteJSON.
+func ReadJSON(r io.Reader) ([]model.Keyword, error) {
+	var docs []jsonKeyword
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&docs); err != nil {
+		return nil, fmt.Errorf("decode keywords: %w", err)
+	}
+
+	keywords := make([]model.Keyword, 0, len(docs))
+	for i, d := range docs {
+		if strings.TrimSpace(d.Keyword) == "" {
+			return nil, fmt.Errorf("keyword %d has no keyword", i)
+		}
+		k := model.Keyword{
+			Keyword:  d.Keyword,
+			Category: d.Category,
+			Enabled:  d.Enabled,
+			Sources:  d.Sources,
+			AddedBy:  d.AddedBy,
+			Added:    d.Added,
+		}
+		if len(d.Stats) > 0 {
+			k.Stats = make(map[string]model.KeywordStats, len(d.Stats))
+			for source, s := range d.Stats {
+				k.Stats[source] = model.KeywordStats(s)
+			}
+		}
+		keywords = append(keywords, k)
+	}
+	return keywords, nil
+}
+
+// WriteJSON writes keywords as an indented JSON array.
+func WriteJSON(w io.Writer, keywords []model.Keyword) error {
+	docs := make([]jsonKeyword, 0, len(keywords))
+	for _, k := range keywords {
+		d := jsonKeyword{
+			Keyword:  k.Keyword,
+			Category: k.Category,
+			Enabled:  k.Enabled,
+			Sources:  k.Sources,
+			AddedBy:  k.AddedBy,
+			Added:    k.Added,
+		}
+		if len(k.Stats) > 0 {
+			d.Stats = make(map[string]jsonStats, len(k.Stats))
+			for source, s := range k.Stats {
+				d.Stats[source] = jsonStats(s)
+			}
+		}
+		docs = append(docs, d)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(docs)
+}
+
+// Read reads keywords in format, which is FormatSeed or FormatJSON.
+func Read(r io.Reader, format, category string) ([]model.Keyword, error) {
+	switch format {
+	case FormatSeed:
+		return ParseSeed(r, category)
+	case FormatJSON:
+		return ReadJSON(r)
+	default:
+		return nil, fmt.Errorf("unknown keyword format %q: want %s or %s", format, FormatSeed, FormatJSON)
+	}
+}
+
+// Write writes keywords in format, which is FormatSeed or FormatJSON.
+func Write(w io.Writer, format string, keywords []model.Keyword) error {
+	switch format {
+	case FormatSeed:
+		return WriteSeed(w, keywords)
+	case FormatJSON:
+		return WriteJSON(w, keywords)
+	default:
+		return fmt.Errorf("unknown keyword format %q: want %s or %s", format, FormatSeed, FormatJSON)
+	}
+}
@@ -2,48 +2,166 @@ package config
 
 import (
 	"errors"
+	"fmt"
+	"log/slog"
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/mindsgn-studio/takealot-scraper/internal/httpx"
 	"github.com/mindsgn-studio/takealot-scraper/internal/model"
 )
 
 const (
-	DefaultDBName     = "snapprice"
-	DefaultItemsColl  = "items"
-	DefaultPricesColl = "prices"
+	DefaultDBName          = "snapprice"
+	DefaultItemsColl       = "items"
+	DefaultPricesColl      = "prices"
+	DefaultWatchesColl     = "watches"
+	DefaultLeasesColl      = "leases"
+	DefaultCheckpointsColl = "checkpoints"
+	DefaultQuarantineColl  = "quarantine"
+	DefaultRunsColl        = "runs"
+	DefaultSchedulesColl   = "schedules"
+	DefaultRecrawlColl     = "recrawl"
+	DefaultKeywordsColl    = "keywords"
+	DefaultDetailsColl     = "details"
+	DefaultDBOpTimeout     = 10 * time.Second
+	DefaultBrandFile       = "brand.txt"
+	DefaultUserAgent       = "snapprice-scraper/1.0 (+https://example.com)"
+	DefaultLogLevel        = "info"
+	DefaultLogFormat       = "text"
+
+	// DefaultFile is the config file read from the working directory when
+	// none is named.
+	DefaultFile = "snapprice.yaml"
 )
 
-func LoadConfig() (model.Config, error) {
-	// load .env if present but don't error if not present
-	_ = godotenv.Load()
+// Options say where LoadConfig finds settings.
+type Options struct {
+	// File names a YAML config file that must exist. When empty,
+	// $SNAPPRICE_CONFIG is used, or DefaultFile if present.
+	File string
+	// EnvFile names an env file that must exist. When empty, a .env in
+	// the working directory is loaded if present.
+	EnvFile string
+	// Sources are the built-in settings of every retailer. The config
+	// file may only have sections for these names.
+	Sources map[string]model.SourceConfig
+}
+
+// Defaults returns the settings used when neither the config file nor the
+// environment says otherwise.
+func Defaults(sources map[string]model.SourceConfig) model.Config {
+	cfg := model.Config{
+		DBName:          DefaultDBName,
+		ItemsColl:       DefaultItemsColl,
+		PricesColl:      DefaultPricesColl,
+		WatchesColl:     DefaultWatchesColl,
+		LeasesColl:      DefaultLeasesColl,
+		CheckpointsColl: DefaultCheckpointsColl,
+		QuarantineColl:  DefaultQuarantineColl,
+		RunsColl:        DefaultRunsColl,
+		SchedulesColl:   DefaultSchedulesColl,
+		RecrawlColl:     DefaultRecrawlColl,
+		KeywordsColl:    DefaultKeywordsColl,
+		DetailsColl:     DefaultDetailsColl,
+		DBOpTimeout:     DefaultDBOpTimeout,
+		BrandFile:       DefaultBrandFile,
+		UserAgent:       DefaultUserAgent,
+		LogLevel:        DefaultLogLevel,
+		LogFormat:       DefaultLogFormat,
+		HTTP:            model.HTTPConfig(httpx.DefaultPolicy()),
+		Sources:         make(map[string]model.SourceConfig, len(sources)),
+	}
+	for name, s := range sources {
+		cfg.Sources[name] = s
+	}
+	return cfg
+}
+
+// LoadConfig layers the defaults, the config file and the environment, in
+// that order. In the environment, the SNAPPRICE_ variable of a setting
+// (see EnvName) beats the older names such as MONGODB_URI. Flags are
+// applied by the caller, which must Validate the result.
+func LoadConfig(opts Options) (model.Config, error) {
+	if opts.EnvFile != "" {
+		if err := godotenv.Load(opts.EnvFile); err != nil {
+			return model.Config{}, fmt.Errorf("load %s: %w", opts.EnvFile, err)
+		}
+	} else {
+		// load .env if present but don't error if not present
+		_ = godotenv.Load()
+	}
+
+	cfg := Defaults(opts.Sources)
+
+	path, required := opts.File, true
+	if path == "" {
+		path = os.Getenv("SNAPPRICE_CONFIG")
+	}
+	if path == "" {
+		path, required = DefaultFile, false
+	}
+	if err := applyFile(&cfg, path, required); err != nil {
+		return model.Config{}, err
+	}
+
+	applyEnv(&cfg)
+	if err := applyOverrideEnv(&cfg); err != nil {
+		return model.Config{}, err
+	}
+	return cfg, nil
+}
 
-	mongoURI := os.Getenv("MONGODB_URI")
-	if mongoURI == "" {
-		return model.Config{}, errors.New("MONGODB_URI not set")
+// applyEnv overrides cfg with the variables that are set.
+func applyEnv(cfg *model.Config) {
+	vars := []struct {
+		name string
+		dst  *string
+	}{
+		{"MONGODB_URI", &cfg.MongoURI},
+		{"POSTGRES_URI", &cfg.PostgresURI},
+		{"MONGO_DB_NAME", &cfg.DBName},
+		{"BRAND_FILE", &cfg.BrandFile},
+		{"USER_AGENT", &cfg.UserAgent},
+		{"LOG_LEVEL", &cfg.LogLevel},
+		{"LOG_FORMAT", &cfg.LogFormat},
+	}
+	for _, v := range vars {
+		if value := os.Getenv(v.name); value != "" {
+			*v.dst = value
+		}
 	}
+}
 
-	db := os.Getenv("MONGO_DB_NAME")
-	if db == "" {
-		db = DefaultDBName
+func RequireMongo(cfg model.Config) error {
+	if cfg.MongoURI == "" {
+		return errors.New("mongo.uri or MONGODB_URI not set")
 	}
+	return nil
+}
 
-	brandFile := os.Getenv("BRAND_FILE")
-	if brandFile == "" {
-		brandFile = "brand.txt"
+func RequirePostgres(cfg model.Config) error {
+	if cfg.PostgresURI == "" {
+		return errors.New("postgres.uri or POSTGRES_URI not set")
 	}
+	return nil
+}
 
-	ua := os.Getenv("USER_AGENT")
-	if ua == "" {
-		ua = "snapprice-scraper/1.0 (+https://example.com)"
+// ParseLogLevel accepts debug, info, warn or error.
+func ParseLogLevel(level string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q (want debug, info, warn or error)", level)
 	}
+	return l, nil
+}
 
-	return model.Config{
-		MongoURI:   mongoURI,
-		DBName:     db,
-		ItemsColl:  DefaultItemsColl,
-		PricesColl: DefaultPricesColl,
-		BrandFile:  brandFile,
-		UserAgent:  ua,
-	}, nil
+func ValidateLogFormat(format string) error {
+	switch format {
+	case "text", "json":
+		return nil
+	default:
+		return fmt.Errorf("unknown log format %q (want text or json)", format)
+	}
 }
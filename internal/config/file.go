@@ -0,0 +1,316 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"gopkg.in/yaml.v3"
+)
+
+// file is the layout of the config file. Every field is optional; pointers
+// tell an explicit zero from a field left out.
+type file struct {
+	LogLevel  string `yaml:"log_level,omitempty"`
+	LogFormat string `yaml:"log_format,omitempty"`
+	BrandFile string `yaml:"brand_file,omitempty"`
+	UserAgent string `yaml:"user_agent,omitempty"`
+
+	Mongo       fileMongo      `yaml:"mongo"`
+	Postgres    filePostgres   `yaml:"postgres"`
+	DBOpTimeout *time.Duration `yaml:"db_op_timeout,omitempty"`
+
+	HTTP    fileHTTP              `yaml:"http"`
+	Sources map[string]fileSource `yaml:"sources,omitempty"`
+}
+
+type fileMongo struct {
+	URI         string          `yaml:"uri,omitempty"`
+	Database    string          `yaml:"database,omitempty"`
+	Collections fileCollections `yaml:"collections"`
+}
+
+type fileCollections struct {
+	Items       string `yaml:"items,omitempty"`
+	Prices      string `yaml:"prices,omitempty"`
+	Watches     string `yaml:"watches,omitempty"`
+	Leases      string `yaml:"leases,omitempty"`
+	Checkpoints string `yaml:"checkpoints,omitempty"`
+	Quarantine  string `yaml:"quarantine,omitempty"`
+	Runs        string `yaml:"runs,omitempty"`
+	Schedules   string `yaml:"schedules,omitempty"`
+	Recrawl     string `yaml:"recrawl,omitempty"`
+	Keywords    string `yaml:"keywords,omitempty"`
+	Details     string `yaml:"details,omitempty"`
+}
+
+type filePostgres struct {
+	URI string `yaml:"uri,omitempty"`
+}
+
+type fileHTTP struct {
+	MaxRetries       *int           `yaml:"max_retries,omitempty"`
+	BaseBackoff      *time.Duration `yaml:"base_backoff,omitempty"`
+	MaxBackoff       *time.Duration `yaml:"max_backoff,omitempty"`
+	MaxRetryAfter    *time.Duration `yaml:"max_retry_after,omitempty"`
+	BreakerThreshold *int           `yaml:"breaker_threshold,omitempty"`
+	BreakerCooldown  *time.Duration `yaml:"breaker_cooldown,omitempty"`
+}
+
+type fileSource struct {
+	UserAgent   string         `yaml:"user_agent,omitempty"`
+	Rate        *float64       `yaml:"rate,omitempty"`
+	Timeout     *time.Duration `yaml:"timeout,omitempty"`
+	DedupWindow *time.Duration `yaml:"dedup_window,omitempty"`
+}
+
+// applyFile overrides cfg with the settings in the config file at path. A
+// missing file is only an error when required.
+func applyFile(cfg *model.Config, path string, required bool) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read config: %w", err)
+	}
+
+	var f file
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if err := f.apply(cfg); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+func (f file) apply(cfg *model.Config) error {
+	setString(&cfg.LogLevel, f.LogLevel)
+	setString(&cfg.LogFormat, f.LogFormat)
+	setString(&cfg.BrandFile, f.BrandFile)
+	setString(&cfg.UserAgent, f.UserAgent)
+
+	setString(&cfg.MongoURI, f.Mongo.URI)
+	setString(&cfg.DBName, f.Mongo.Database)
+	c := f.Mongo.Collections
+	setString(&cfg.ItemsColl, c.Items)
+	setString(&cfg.PricesColl, c.Prices)
+	setString(&cfg.WatchesColl, c.Watches)
+	setString(&cfg.LeasesColl, c.Leases)
+	setString(&cfg.CheckpointsColl, c.Checkpoints)
+	setString(&cfg.QuarantineColl, c.Quarantine)
+	setString(&cfg.RunsColl, c.Runs)
+	setString(&cfg.SchedulesColl, c.Schedules)
+	setString(&cfg.RecrawlColl, c.Recrawl)
+	setString(&cfg.KeywordsColl, c.Keywords)
+	setString(&cfg.DetailsColl, c.Details)
+	setString(&cfg.PostgresURI, f.Postgres.URI)
+	set(&cfg.DBOpTimeout, f.DBOpTimeout)
+
+	set(&cfg.HTTP.MaxRetries, f.HTTP.MaxRetries)
+	set(&cfg.HTTP.BaseBackoff, f.HTTP.BaseBackoff)
+	set(&cfg.HTTP.MaxBackoff, f.HTTP.MaxBackoff)
+	set(&cfg.HTTP.MaxRetryAfter, f.HTTP.MaxRetryAfter)
+	set(&cfg.HTTP.BreakerThreshold, f.HTTP.BreakerThreshold)
+	set(&cfg.HTTP.BreakerCooldown, f.HTTP.BreakerCooldown)
+
+	for name, fs := range f.Sources {
+		s, ok := cfg.Sources[name]
+		if !ok {
+			return fmt.Errorf("sources: unknown source %q (want %s)", name, strings.Join(sourceNames(*cfg), ", "))
+		}
+		setString(&s.UserAgent, fs.UserAgent)
+		set(&s.RequestsPerSecond, fs.Rate)
+		set(&s.Timeout, fs.Timeout)
+		set(&s.PriceDedupWindow, fs.DedupWindow)
+		cfg.Sources[name] = s
+	}
+	return nil
+}
+
+func setString(dst *string, v string) {
+	if v != "" {
+		*dst = v
+	}
+}
+
+func set[T any](dst *T, v *T) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+func sourceNames(cfg model.Config) []string {
+	names := make([]string, 0, len(cfg.Sources))
+	for name := range cfg.Sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate reports every setting that is out of range, named by its place
+// in the config file.
+func Validate(cfg model.Config) error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if _, err := ParseLogLevel(cfg.LogLevel); err != nil {
+		fail("log_level: %w", err)
+	}
+	if err := ValidateLogFormat(cfg.LogFormat); err != nil {
+		fail("log_format: %w", err)
+	}
+	if cfg.BrandFile == "" {
+		fail("brand_file: must not be empty")
+	}
+	if cfg.DBName == "" {
+		fail("mongo.database: must not be empty")
+	}
+
+	collections := []struct{ key, name string }{
+		{"items", cfg.ItemsColl},
+		{"prices", cfg.PricesColl},
+		{"watches", cfg.WatchesColl},
+		{"leases", cfg.LeasesColl},
+		{"checkpoints", cfg.CheckpointsColl},
+		{"quarantine", cfg.QuarantineColl},
+		{"runs", cfg.RunsColl},
+		{"schedules", cfg.SchedulesColl},
+		{"recrawl", cfg.RecrawlColl},
+		{"keywords", cfg.KeywordsColl},
+		{"details", cfg.DetailsColl},
+	}
+	used := make(map[string]string)
+	for _, c := range collections {
+		if c.name == "" {
+			fail("mongo.collections.%s: must not be empty", c.key)
+			continue
+		}
+		if other, dup := used[c.name]; dup {
+			fail("mongo.collections.%s: %q is already used by %s", c.key, c.name, other)
+			continue
+		}
+		used[c.name] = c.key
+	}
+
+	if cfg.DBOpTimeout <= 0 {
+		fail("db_op_timeout: must be positive")
+	}
+
+	h := cfg.HTTP
+	if h.MaxRetries < 0 {
+		fail("http.max_retries: must not be negative")
+	}
+	if h.BaseBackoff <= 0 {
+		fail("http.base_backoff: must be positive")
+	}
+	if h.MaxBackoff < h.BaseBackoff {
+		fail("http.max_backoff: must be at least http.base_backoff (%s)", h.BaseBackoff)
+	}
+	if h.MaxRetryAfter < 0 {
+		fail("http.max_retry_after: must not be negative")
+	}
+	if h.BreakerThreshold < 1 {
+		fail("http.breaker_threshold: must be at least 1")
+	}
+	if h.BreakerCooldown <= 0 {
+		fail("http.breaker_cooldown: must be positive")
+	}
+
+	for _, name := range sourceNames(cfg) {
+		s := cfg.Sources[name]
+		if s.RequestsPerSecond <= 0 {
+			fail("sources.%s.rate: must be positive", name)
+		}
+		if s.Timeout <= 0 {
+			fail("sources.%s.timeout: must be positive", name)
+		}
+		if s.PriceDedupWindow < 0 {
+			fail("sources.%s.dedup_window: must not be negative", name)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// Write prints cfg in the config file format with every setting filled in
+// and passwords in URIs redacted.
+func Write(w io.Writer, cfg model.Config) error {
+	f := file{
+		LogLevel:  cfg.LogLevel,
+		LogFormat: cfg.LogFormat,
+		BrandFile: cfg.BrandFile,
+		UserAgent: cfg.UserAgent,
+		Mongo: fileMongo{
+			URI:      redact(cfg.MongoURI),
+			Database: cfg.DBName,
+			Collections: fileCollections{
+				Items:       cfg.ItemsColl,
+				Prices:      cfg.PricesColl,
+				Watches:     cfg.WatchesColl,
+				Leases:      cfg.LeasesColl,
+				Checkpoints: cfg.CheckpointsColl,
+				Quarantine:  cfg.QuarantineColl,
+				Runs:        cfg.RunsColl,
+				Schedules:   cfg.SchedulesColl,
+				Recrawl:     cfg.RecrawlColl,
+				Keywords:    cfg.KeywordsColl,
+				Details:     cfg.DetailsColl,
+			},
+		},
+		Postgres:    filePostgres{URI: redact(cfg.PostgresURI)},
+		DBOpTimeout: &cfg.DBOpTimeout,
+		HTTP: fileHTTP{
+			MaxRetries:       &cfg.HTTP.MaxRetries,
+			BaseBackoff:      &cfg.HTTP.BaseBackoff,
+			MaxBackoff:       &cfg.HTTP.MaxBackoff,
+			MaxRetryAfter:    &cfg.HTTP.MaxRetryAfter,
+			BreakerThreshold: &cfg.HTTP.BreakerThreshold,
+			BreakerCooldown:  &cfg.HTTP.BreakerCooldown,
+		},
+		Sources: make(map[string]fileSource, len(cfg.Sources)),
+	}
+	for _, name := range sourceNames(cfg) {
+		s := cfg.Source(name)
+		f.Sources[name] = fileSource{
+			UserAgent:   s.UserAgent,
+			Rate:        &s.RequestsPerSecond,
+			Timeout:     &s.Timeout,
+			DedupWindow: &s.PriceDedupWindow,
+		}
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(f); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func redact(uri string) string {
+	if uri == "" {
+		return ""
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "<unparsable>"
+	}
+	return u.Redacted()
+}
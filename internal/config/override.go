@@ -0,0 +1,94 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix starts the variable that overrides each config file setting:
+// sources.amazon.rate is SNAPPRICE_SOURCES_AMAZON_RATE.
+const EnvPrefix = "SNAPPRICE_"
+
+// Set overrides one config file setting, named by its dotted key such as
+// http.max_retries or sources.amazon.rate. The value is read as it would
+// be in the file.
+func Set(cfg *model.Config, key, value string) error {
+	if !slices.Contains(Keys(*cfg), key) {
+		return errors.New("unknown setting")
+	}
+	doc := &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+	parts := strings.Split(key, ".")
+	for i := len(parts) - 1; i >= 0; i-- {
+		name := &yaml.Node{Kind: yaml.ScalarNode, Value: parts[i]}
+		doc = &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{name, doc}}
+	}
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	var f file
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil {
+		return err
+	}
+	return f.apply(cfg)
+}
+
+// EnvName is the variable that overrides the setting at key.
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// Keys lists every setting's dotted key, with a section for each of cfg's
+// sources.
+func Keys(cfg model.Config) []string {
+	return fileKeys(reflect.TypeFor[file](), "", sourceNames(cfg))
+}
+
+func fileKeys(t reflect.Type, prefix string, sources []string) []string {
+	var keys []string
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		key := prefix + name
+		typ := field.Type
+		if typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+		switch typ.Kind() {
+		case reflect.Struct:
+			keys = append(keys, fileKeys(typ, key+".", sources)...)
+		case reflect.Map:
+			for _, source := range sources {
+				keys = append(keys, fileKeys(typ.Elem(), key+"."+source+".", sources)...)
+			}
+		default:
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// applyOverrideEnv overrides cfg with every SNAPPRICE_ variable that is
+// set.
+func applyOverrideEnv(cfg *model.Config) error {
+	for _, key := range Keys(*cfg) {
+		name := EnvName(key)
+		if value := os.Getenv(name); value != "" {
+			if err := Set(cfg, key, value); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
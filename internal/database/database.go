@@ -0,0 +1,54 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "github.com/lib/pq"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	DefaultConnectTimeout = 30 * time.Second
+	DefaultCloseTimeout   = 10 * time.Second
+)
+
+func ConnectMongo(parentCtx context.Context, uri string) (*mongo.Client, error) {
+	ctx, cancel := context.WithTimeout(parentCtx, DefaultConnectTimeout)
+	defer cancel()
+
+	clientOptions := options.Client().
+		ApplyURI(uri).
+		SetConnectTimeout(DefaultConnectTimeout).
+		SetServerSelectionTimeout(DefaultConnectTimeout).
+		SetMaxPoolSize(10).
+		SetMinPoolSize(1)
+
+	client, err := mongo.Connect(ctx, clientOptions)
+	if err != nil {
+		return nil, fmt.Errorf("mongo connect: %w", err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		_ = client.Disconnect(ctx)
+		return nil, fmt.Errorf("mongo ping: %w", err)
+	}
+	return client, nil
+}
+
+func ConnectPostgres(parentCtx context.Context, uri string) (*sql.DB, error) {
+	ctx, cancel := context.WithTimeout(parentCtx, DefaultConnectTimeout)
+	defer cancel()
+
+	db, err := sql.Open("postgres", uri)
+	if err != nil {
+		return nil, fmt.Errorf("postgres open: %w", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("postgres ping: %w", err)
+	}
+	return db, nil
+}
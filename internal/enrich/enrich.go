@@ -0,0 +1,161 @@
+// Package enrich fills in what search listings leave out — descriptions,
+// specifications, categories, barcodes, warranty and ratings — from each
+// item's product page, and fetches them again once they go stale.
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/health"
+	"github.com/mindsgn-studio/takealot-scraper/internal/metrics"
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"github.com/mindsgn-studio/takealot-scraper/internal/store"
+)
+
+const DefaultMaxAge = 7 * 24 * time.Hour
+
+// Fetcher reads a product's details from its retailer. Requests are rate
+// limited by the fetcher's transport, not here.
+type Fetcher interface {
+	Name() string
+	Details(ctx context.Context, sourceID string) (model.Details, error)
+}
+
+// Options tune which items a pass fetches.
+type Options struct {
+	// MaxAge is how old details may get before they are fetched again.
+	MaxAge time.Duration
+	// ItemsPerPass, when positive, caps how many items a pass fetches,
+	// items never fetched first and then the stalest.
+	ItemsPerPass int
+}
+
+type Enricher struct {
+	cfg      model.Config
+	fetcher  Fetcher
+	store    store.Store
+	details  store.Detailer
+	opts     Options
+	logger   *slog.Logger
+	progress health.Progress
+}
+
+func New(cfg model.Config, fetcher Fetcher, st store.Store, details store.Detailer, opts Options, logger *slog.Logger) *Enricher {
+	e := &Enricher{
+		cfg:     cfg,
+		fetcher: fetcher,
+		store:   st,
+		details: details,
+		opts:    opts,
+		logger:  logger,
+	}
+	e.progress.Touch()
+	return e
+}
+
+// LastProgress is when the enricher last got a product page back,
+// successful or not.
+func (e *Enricher) LastProgress() time.Time {
+	return e.progress.Last()
+}
+
+// stale is an item due for a fetch and when it was last fetched, zero for
+// never.
+type stale struct {
+	item    model.Item
+	fetched time.Time
+}
+
+// Run fetches the details of every item of the fetcher's source that has
+// none or whose details are older than opts.MaxAge.
+func (e *Enricher) Run(ctx context.Context) error {
+	e.progress.Touch()
+	due, err := e.due(ctx, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	e.logger.Info("starting enrichment", "items", len(due))
+
+	source := e.fetcher.Name()
+	var saved, failed int
+	for _, s := range due {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		log := e.logger.With("item_id", s.item.ID, "source_id", s.item.SourceID)
+
+		d, err := e.fetcher.Details(ctx, s.item.SourceID)
+		e.progress.Touch()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			metrics.DetailsFetched.WithLabelValues(source, "error").Inc()
+			log.Error("fetch details", "error", err)
+			failed++
+			continue
+		}
+		metrics.DetailsFetched.WithLabelValues(source, "ok").Inc()
+
+		d.ItemID = s.item.ID
+		d.Source = source
+		d.SourceID = s.item.SourceID
+		d.Fetched = time.Now().UTC()
+		if e.cfg.DryRun {
+			log.Info("dry-run: would save details", "categories", d.Categories, "barcodes", d.Barcodes, "rating", d.Rating, "reviews", d.Reviews)
+			continue
+		}
+		if err := e.details.SaveDetails(ctx, d); err != nil {
+			log.Error("save details", "error", err)
+			failed++
+			continue
+		}
+		log.Debug("saved details", "specs", len(d.Specs), "categories", len(d.Categories))
+		saved++
+	}
+	e.logger.Info("enrichment finished", "saved", saved, "failed", failed)
+	return nil
+}
+
+// due returns the items whose details are missing or older than
+// opts.MaxAge, never fetched first and then oldest first, trimmed to
+// opts.ItemsPerPass.
+func (e *Enricher) due(ctx context.Context, now time.Time) ([]stale, error) {
+	source := e.fetcher.Name()
+	fetched, err := e.details.DetailsFetched(ctx, source)
+	if err != nil {
+		return nil, fmt.Errorf("load details fetch times: %w", err)
+	}
+
+	var due []stale
+	fresh := 0
+	err = e.store.EachItem(ctx, func(item model.Item) error {
+		if item.Source != source || item.SourceID == "" {
+			return nil
+		}
+		at, ok := fetched[item.ID]
+		if ok && now.Sub(at) < e.opts.MaxAge {
+			fresh++
+			return nil
+		}
+		due = append(due, stale{item: item, fetched: at})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read items: %w", err)
+	}
+
+	sort.SliceStable(due, func(i, j int) bool { return due[i].fetched.Before(due[j].fetched) })
+	if n := e.opts.ItemsPerPass; n > 0 && n < len(due) {
+		e.logger.Info("limiting enrichment to stalest items", "items", n, "deferred", len(due)-n)
+		due = due[:n]
+	}
+	if fresh > 0 {
+		e.logger.Debug("skipping items with fresh details", "count", fresh)
+	}
+	return due, nil
+}
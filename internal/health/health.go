@@ -0,0 +1,122 @@
+// Package health answers liveness and readiness probes so a supervisor can
+// restart a process that is up but no longer getting anywhere.
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultCheckTimeout = 5 * time.Second
+	DefaultStallAfter   = 15 * time.Minute
+)
+
+// Check returns why a dependency is not ready, or nil when it is.
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker runs its checks for every /readyz request and answers 503 if any
+// of them fail.
+type Checker struct {
+	mu     sync.Mutex
+	checks []namedCheck
+}
+
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+func (c *Checker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	checks := append([]namedCheck(nil), c.checks...)
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(r.Context(), DefaultCheckTimeout)
+	defer cancel()
+
+	status := http.StatusOK
+	var body strings.Builder
+	for _, nc := range checks {
+		if err := nc.check(ctx); err != nil {
+			status = http.StatusServiceUnavailable
+			fmt.Fprintf(&body, "%s: %v\n", nc.name, err)
+			continue
+		}
+		fmt.Fprintf(&body, "%s: ok\n", nc.name)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(body.String()))
+}
+
+// Live answers /healthz: the process is running and serving HTTP.
+func Live(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte("ok\n"))
+}
+
+// Progress records when a long-running job last moved forward. The zero
+// value has made no progress; call Touch when the job starts.
+type Progress struct {
+	last atomic.Int64
+}
+
+func (p *Progress) Touch() {
+	p.last.Store(time.Now().UnixNano())
+}
+
+func (p *Progress) Last() time.Time {
+	n := p.last.Load()
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+// Stalled fails once last reports no progress for longer than after.
+func Stalled(last func() time.Time, after time.Duration) Check {
+	return func(context.Context) error {
+		t := last()
+		if t.IsZero() {
+			return fmt.Errorf("no progress yet")
+		}
+		if since := time.Since(t); since > after {
+			return fmt.Errorf("no progress for %s", since.Round(time.Second))
+		}
+		return nil
+	}
+}
+
+// Degraded fails while degraded reports any source whose pages keep
+// failing extraction.
+func Degraded(degraded func() []string) Check {
+	return func(context.Context) error {
+		if sources := degraded(); len(sources) > 0 {
+			return fmt.Errorf("schema drift on %s", strings.Join(sources, ", "))
+		}
+		return nil
+	}
+}
+
+// Circuits fails while open reports any host whose circuit breaker is open.
+func Circuits(open func() []string) Check {
+	return func(context.Context) error {
+		if hosts := open(); len(hosts) > 0 {
+			return fmt.Errorf("circuit open for %s", strings.Join(hosts, ", "))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,86 @@
+package httpx
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without making a request while a host's circuit
+// breaker is open.
+var ErrCircuitOpen = errors.New("circuit open")
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	// breakerHalfOpen lets a single probe through after the cooldown.
+	breakerHalfOpen
+)
+
+// breaker trips after threshold consecutive failed requests to a host and
+// refuses requests until cooldown has passed.
+type breaker struct {
+	mu        sync.Mutex
+	state     breakerState
+	failures  int
+	openUntil time.Time
+}
+
+func (b *breaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if now.Before(b.openUntil) {
+			return false
+		}
+		b.state = breakerHalfOpen
+		return true
+	case breakerHalfOpen:
+		return false
+	default:
+		return true
+	}
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = breakerClosed
+	b.failures = 0
+}
+
+// failure records a failed request and reports whether the breaker opened.
+func (b *breaker) failure(now time.Time, threshold int, cooldown time.Duration) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= threshold {
+		opened := b.state != breakerOpen
+		b.state = breakerOpen
+		b.openUntil = now.Add(cooldown)
+		return opened
+	}
+	return false
+}
+
+// release ends a request without a verdict on the host, such as one the
+// caller cancelled. A half-open breaker goes back to waiting for a probe,
+// which the next request becomes; failures are not counted.
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == breakerHalfOpen {
+		b.state = breakerOpen
+	}
+}
+
+func (b *breaker) open(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state == breakerOpen && now.Before(b.openUntil)
+}
@@ -0,0 +1,125 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Class says whether a failed request is worth repeating.
+type Class int
+
+const (
+	// Permanent failures will not succeed on retry: 4xx responses, bad
+	// requests, cancelled contexts.
+	Permanent Class = iota
+	// Transient failures are network errors and 5xx responses.
+	Transient
+	// Throttled failures are 429 and 503 responses; the server may say
+	// how long to wait with Retry-After.
+	Throttled
+)
+
+func (c Class) String() string {
+	switch c {
+	case Transient:
+		return "transient"
+	case Throttled:
+		return "throttled"
+	default:
+		return "permanent"
+	}
+}
+
+// maxDrain is how much of an unwanted body is read so the connection can be
+// reused; anything longer is abandoned.
+const maxDrain = 64 << 10
+
+// StatusError is returned for a response with an unexpected status.
+type StatusError struct {
+	StatusCode int
+	URL        string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: status %d", e.URL, e.StatusCode)
+}
+
+// Class reports how the status should be treated.
+func (e *StatusError) Class() Class {
+	return classifyStatus(e.StatusCode)
+}
+
+// Classify sorts the outcome of a round trip. A nil err with a 2xx or 3xx
+// response is not a failure and is reported as Permanent.
+func Classify(resp *http.Response, err error) Class {
+	if err != nil {
+		var statusErr *StatusError
+		switch {
+		case errors.As(err, &statusErr):
+			return statusErr.Class()
+		case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded), errors.Is(err, ErrCircuitOpen), errors.Is(err, ErrNoFixture):
+			return Permanent
+		default:
+			return Transient
+		}
+	}
+	if resp == nil {
+		return Permanent
+	}
+	return classifyStatus(resp.StatusCode)
+}
+
+func classifyStatus(code int) Class {
+	switch {
+	case code == http.StatusTooManyRequests, code == http.StatusServiceUnavailable:
+		return Throttled
+	case code == http.StatusRequestTimeout, code >= 500:
+		return Transient
+	default:
+		return Permanent
+	}
+}
+
+// CheckResponse returns a *StatusError, after draining and closing the
+// body, unless resp has a 2xx status.
+func CheckResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	DrainBody(resp.Body)
+	return &StatusError{StatusCode: resp.StatusCode, URL: resp.Request.URL.String()}
+}
+
+// DrainBody reads what is left of body, up to a limit, and closes it so the
+// underlying connection goes back to the pool.
+func DrainBody(body io.ReadCloser) {
+	if body == nil {
+		return
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(body, maxDrain))
+	_ = body.Close()
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP
+// date. It returns zero when the header is missing or unparsable.
+func retryAfter(resp *http.Response, now time.Time) time.Duration {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
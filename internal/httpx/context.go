@@ -0,0 +1,26 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+)
+
+// BindContext returns a RoundTripper that sends every request through base
+// under ctx. It is for clients such as colly that build their requests
+// without a context, so that cancelling ctx also aborts requests already in
+// flight. A nil base uses http.DefaultTransport.
+func BindContext(ctx context.Context, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &contextTransport{ctx: ctx, base: base}
+}
+
+type contextTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.base.RoundTrip(req.WithContext(t.ctx))
+}
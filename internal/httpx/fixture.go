@@ -0,0 +1,170 @@
+package httpx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrNoFixture is returned in replay mode for a request that was never
+// recorded.
+var ErrNoFixture = errors.New("no recorded fixture")
+
+// fixtureMeta is stored next to each recorded body.
+type fixtureMeta struct {
+	Method   string      `json:"method"`
+	URL      string      `json:"url"`
+	Status   int         `json:"status"`
+	Header   http.Header `json:"header"`
+	Recorded time.Time   `json:"recorded"`
+}
+
+// fixturePath returns where a request's fixture lives: one directory per
+// host, files named after a hash of the method and URL.
+func fixturePath(dir string, req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.Method + " " + req.URL.String()))
+	return filepath.Join(dir, hostKey(req.URL.Hostname()), hex.EncodeToString(sum[:12]))
+}
+
+// Record wraps base so every response is also written to dir. A nil base
+// uses http.DefaultTransport. Repeated requests overwrite the earlier
+// fixture.
+func Record(dir string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &recordTransport{dir: dir, base: base}
+}
+
+type recordTransport struct {
+	dir  string
+	base http.RoundTripper
+}
+
+func (t *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read body for recording: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	meta := fixtureMeta{
+		Method:   req.Method,
+		URL:      req.URL.String(),
+		Status:   resp.StatusCode,
+		Header:   resp.Header,
+		Recorded: time.Now().UTC(),
+	}
+	if err := writeFixture(fixturePath(t.dir, req), meta, body); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func writeFixture(path string, meta fixtureMeta, body []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create fixture dir: %w", err)
+	}
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode fixture: %w", err)
+	}
+	if err := os.WriteFile(path+".json", data, 0o644); err != nil {
+		return fmt.Errorf("write fixture: %w", err)
+	}
+	if err := os.WriteFile(path+".body", body, 0o644); err != nil {
+		return fmt.Errorf("write fixture body: %w", err)
+	}
+	return nil
+}
+
+// Replay serves responses recorded by Record from dir and never touches the
+// network.
+func Replay(dir string) http.RoundTripper {
+	return &replayTransport{dir: dir}
+}
+
+type replayTransport struct {
+	dir string
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	path := fixturePath(t.dir, req)
+	data, err := os.ReadFile(path + ".json")
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w (looked for %s.json)", ErrNoFixture, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read fixture: %w", err)
+	}
+	var meta fixtureMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("decode fixture %s: %w", path, err)
+	}
+	body, err := os.ReadFile(path + ".body")
+	if err != nil {
+		return nil, fmt.Errorf("read fixture body: %w", err)
+	}
+
+	if req.Body != nil {
+		DrainBody(req.Body)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", meta.Status, http.StatusText(meta.Status)),
+		StatusCode:    meta.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        meta.Header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// StaticBody answers every request with a 200 carrying body. It lets a
+// scraper that only knows how to fetch, such as a colly collector, parse a
+// page that was captured earlier.
+func StaticBody(body []byte, contentType string) http.RoundTripper {
+	return &staticTransport{body: body, contentType: contentType}
+}
+
+type staticTransport struct {
+	body        []byte
+	contentType string
+}
+
+func (t *staticTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		DrainBody(req.Body)
+	}
+	header := http.Header{}
+	if t.contentType != "" {
+		header.Set("Content-Type", t.contentType)
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(t.body)),
+		ContentLength: int64(len(t.body)),
+		Request:       req,
+	}, nil
+}
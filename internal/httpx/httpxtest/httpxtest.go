@@ -0,0 +1,42 @@
+// Package httpxtest provides RoundTrippers for testing the sources.
+package httpxtest
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// Counter counts the requests that reach its RoundTripper.
+type Counter struct {
+	http.RoundTripper
+	n atomic.Int32
+}
+
+func (c *Counter) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.n.Add(1)
+	return c.RoundTripper.RoundTrip(req)
+}
+
+// Requests is how many requests have been sent.
+func (c *Counter) Requests() int {
+	return int(c.n.Load())
+}
+
+// Hang answers no request; each one fails with its context's error once
+// that context is done. started, when not nil, receives every request as
+// it arrives.
+func Hang(started chan<- *http.Request) http.RoundTripper {
+	return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if started != nil {
+			started <- req
+		}
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	})
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,75 @@
+// Package httpx holds the HTTP plumbing shared by every source.
+package httpx
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// HostLimiter is a token bucket per host, shared by every request made
+// through its Transport. Hosts without their own limit use the default.
+type HostLimiter struct {
+	mu           sync.Mutex
+	limiters     map[string]*rate.Limiter
+	defaultLimit rate.Limit
+	defaultBurst int
+}
+
+// NewHostLimiter returns a limiter that allows limit requests per second,
+// with bursts of burst, to any host not configured with SetLimit.
+func NewHostLimiter(limit rate.Limit, burst int) *HostLimiter {
+	return &HostLimiter{
+		limiters:     make(map[string]*rate.Limiter),
+		defaultLimit: limit,
+		defaultBurst: burst,
+	}
+}
+
+// SetLimit gives host its own request budget.
+func (l *HostLimiter) SetLimit(host string, limit rate.Limit, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limiters[hostKey(host)] = rate.NewLimiter(limit, burst)
+}
+
+func (l *HostLimiter) limiter(host string) *rate.Limiter {
+	key := hostKey(host)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lim, ok := l.limiters[key]
+	if !ok {
+		lim = rate.NewLimiter(l.defaultLimit, l.defaultBurst)
+		l.limiters[key] = lim
+	}
+	return lim
+}
+
+// Transport wraps base so every request waits for its host's token first.
+// A nil base uses http.DefaultTransport.
+func (l *HostLimiter) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &limitedTransport{limiter: l, base: base}
+}
+
+type limitedTransport struct {
+	limiter *HostLimiter
+	base    http.RoundTripper
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.limiter(req.URL.Hostname()).Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
+
+// hostKey folds www.example.com and example.com into one budget.
+func hostKey(host string) string {
+	return strings.TrimPrefix(strings.ToLower(host), "www.")
+}
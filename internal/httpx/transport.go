@@ -0,0 +1,284 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/metrics"
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+)
+
+const (
+	DefaultMaxRetries       = 3
+	DefaultBaseBackoff      = 500 * time.Millisecond
+	DefaultMaxBackoff       = 30 * time.Second
+	DefaultMaxRetryAfter    = 2 * time.Minute
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = time.Minute
+)
+
+// Policy decides how a Transport retries and when it stops talking to a
+// host altogether.
+type Policy struct {
+	// MaxRetries is how many times a transient or throttled request is
+	// repeated after the first attempt.
+	MaxRetries int
+	// BaseBackoff doubles on every retry, with jitter, up to MaxBackoff.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// MaxRetryAfter caps how long a server's Retry-After header may make
+	// us wait; a longer wait gives up and returns the response. Our own
+	// backoff is bounded by MaxBackoff instead.
+	MaxRetryAfter time.Duration
+	// BreakerThreshold consecutive failed requests to a host open its
+	// circuit for BreakerCooldown.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxRetries:       DefaultMaxRetries,
+		BaseBackoff:      DefaultBaseBackoff,
+		MaxBackoff:       DefaultMaxBackoff,
+		MaxRetryAfter:    DefaultMaxRetryAfter,
+		BreakerThreshold: DefaultBreakerThreshold,
+		BreakerCooldown:  DefaultBreakerCooldown,
+	}
+}
+
+// ConfiguredPolicy returns the policy set in the config file.
+func ConfiguredPolicy(c model.HTTPConfig) Policy {
+	return Policy(c)
+}
+
+// Transport retries transient and throttled failures according to its
+// Policy and keeps a circuit breaker per host. Responses that are still
+// failing once retries run out are returned as they are, so callers see the
+// real status; use CheckResponse to turn them into errors.
+type Transport struct {
+	base   http.RoundTripper
+	policy Policy
+	logger *slog.Logger
+	// attemptTimeout bounds each attempt, reading the body included, so
+	// that retries and Retry-After waits do not eat into it.
+	attemptTimeout time.Duration
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+// NewTransport wraps base, or http.DefaultTransport when base is nil.
+// logger may be nil.
+func NewTransport(base http.RoundTripper, policy Policy, logger *slog.Logger) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{
+		base:     base,
+		policy:   policy,
+		logger:   logger,
+		breakers: make(map[string]*breaker),
+	}
+}
+
+// SetAttemptTimeout bounds every attempt at a request, including reading
+// its body; zero leaves attempts unbounded. Callers should not also set
+// http.Client.Timeout, which would cover the retries as well. It must be
+// called before the Transport is used.
+func (t *Transport) SetAttemptTimeout(d time.Duration) {
+	t.attemptTimeout = d
+}
+
+func (t *Transport) breaker(host string) *breaker {
+	key := hostKey(host)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b, ok := t.breakers[key]
+	if !ok {
+		b = &breaker{}
+		t.breakers[key] = b
+	}
+	return b
+}
+
+// OpenCircuits lists the hosts currently refusing requests.
+func (t *Transport) OpenCircuits() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	var hosts []string
+	for host, b := range t.breakers {
+		if b.open(now) {
+			hosts = append(hosts, host)
+		}
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Hostname()
+	b := t.breaker(host)
+	// Every return below records a success or failure on b, or releases
+	// it when the caller gave up: a half-open breaker refuses all requests
+	// until its probe reports back.
+	if !b.allow(time.Now()) {
+		return nil, fmt.Errorf("%s: %w", hostKey(host), ErrCircuitOpen)
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := t.attempt(req)
+		countRequest(host, resp, err)
+		if err == nil && resp.StatusCode < 400 {
+			b.success()
+			return resp, nil
+		}
+		if req.Context().Err() != nil {
+			// The caller gave up; that says nothing about the host.
+			b.release()
+			return resp, err
+		}
+
+		class := Classify(resp, err)
+		if errors.Is(err, errAttemptTimeout) {
+			class = Transient
+		}
+		if class == Permanent {
+			// The host answered; it is the request that is wrong.
+			if err == nil {
+				b.success()
+			} else {
+				t.fail(b, host)
+			}
+			return resp, err
+		}
+
+		wait := t.backoff(attempt)
+		tooLong := false
+		if class == Throttled && resp != nil {
+			if after := retryAfter(resp, time.Now()); after > 0 {
+				wait = after
+				tooLong = after > t.policy.MaxRetryAfter
+			}
+		}
+		if attempt >= t.policy.MaxRetries || tooLong || !rewindable(req) {
+			t.fail(b, host)
+			return resp, err
+		}
+
+		if resp != nil {
+			DrainBody(resp.Body)
+		}
+		metrics.HTTPRetries.WithLabelValues(hostKey(host), class.String()).Inc()
+		if t.logger != nil {
+			var reason string
+			if err != nil {
+				reason = err.Error()
+			} else {
+				reason = resp.Status
+			}
+			t.logger.Warn("retrying request",
+				"method", req.Method,
+				"url", req.URL.Redacted(),
+				"attempt", attempt+1,
+				"class", class.String(),
+				"wait", wait.Round(time.Millisecond),
+				"reason", reason,
+			)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			b.release()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+
+		if req.Body != nil && req.Body != http.NoBody {
+			body, err := req.GetBody()
+			if err != nil {
+				t.fail(b, host)
+				return nil, fmt.Errorf("rewind request body: %w", err)
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// errAttemptTimeout marks an attempt cut short by the attempt timeout
+// rather than by the caller, so it can be retried.
+var errAttemptTimeout = errors.New("attempt timed out")
+
+// attempt sends req once, bounded by the attempt timeout. The timeout keeps
+// running while the body is read and is released when it is closed.
+func (t *Transport) attempt(req *http.Request) (*http.Response, error) {
+	if t.attemptTimeout <= 0 {
+		return t.base.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), t.attemptTimeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		if req.Context().Err() == nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("%w after %s: %w", errAttemptTimeout, t.attemptTimeout, err)
+		}
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelBody releases an attempt's context once its body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+func (t *Transport) fail(b *breaker, host string) {
+	if b.failure(time.Now(), t.policy.BreakerThreshold, t.policy.BreakerCooldown) && t.logger != nil {
+		t.logger.Warn("circuit open", "host", hostKey(host), "cooldown", t.policy.BreakerCooldown)
+	}
+}
+
+func (t *Transport) backoff(attempt int) time.Duration {
+	wait := t.policy.BaseBackoff << attempt
+	if wait <= 0 || wait > t.policy.MaxBackoff {
+		wait = t.policy.MaxBackoff
+	}
+	jitter := time.Duration(rand.Int63n(int64(wait)/2 + 1))
+	return wait/2 + jitter
+}
+
+func countRequest(host string, resp *http.Response, err error) {
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	metrics.HTTPRequests.WithLabelValues(hostKey(host), status).Inc()
+}
+
+// rewindable reports whether req can be sent again.
+func rewindable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
@@ -0,0 +1,321 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func respond(req *http.Request, status int) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}
+}
+
+// statuses answers each attempt with the next status in turn, repeating the
+// last one, and counts the attempts.
+func statuses(attempts *atomic.Int32, codes ...int) roundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		n := int(attempts.Add(1))
+		return respond(req, codes[min(n, len(codes))-1]), nil
+	}
+}
+
+func fastPolicy() Policy {
+	policy := DefaultPolicy()
+	policy.BaseBackoff = time.Millisecond
+	policy.MaxBackoff = 2 * time.Millisecond
+	return policy
+}
+
+func get(t *testing.T, tr *Transport) (*http.Response, error) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, "https://example.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := tr.RoundTrip(req)
+	if resp != nil {
+		resp.Body.Close()
+	}
+	return resp, err
+}
+
+func TestTransportRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		codes    []int
+		status   int
+		attempts int32
+	}{
+		{"success", []int{200}, 200, 1},
+		{"transient then success", []int{502, 500, 200}, 200, 3},
+		{"throttled then success", []int{429, 200}, 200, 2},
+		{"retries run out", []int{503}, 503, 1 + DefaultMaxRetries},
+		{"permanent is not retried", []int{404, 200}, 404, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			tr := NewTransport(statuses(&attempts, tt.codes...), fastPolicy(), nil)
+
+			resp, err := get(t, tr)
+			if err != nil {
+				t.Fatalf("RoundTrip: %v", err)
+			}
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if got := attempts.Load(); got != tt.attempts {
+				t.Errorf("attempts = %d, want %d", got, tt.attempts)
+			}
+		})
+	}
+}
+
+func TestTransportRetryAfter(t *testing.T) {
+	var attempts atomic.Int32
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if attempts.Add(1) == 1 {
+			resp := respond(req, http.StatusTooManyRequests)
+			resp.Header.Set("Retry-After", "1")
+			return resp, nil
+		}
+		return respond(req, http.StatusOK), nil
+	})
+	tr := NewTransport(base, fastPolicy(), nil)
+
+	start := time.Now()
+	resp, err := get(t, tr)
+	if err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+	if waited := time.Since(start); waited < time.Second {
+		t.Errorf("retried after %s, want the server's 1s", waited)
+	}
+}
+
+func TestTransportRetryAfterTooLong(t *testing.T) {
+	var attempts atomic.Int32
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		attempts.Add(1)
+		resp := respond(req, http.StatusServiceUnavailable)
+		resp.Header.Set("Retry-After", "3600")
+		return resp, nil
+	})
+	tr := NewTransport(base, fastPolicy(), nil)
+
+	resp, err := get(t, tr)
+	if err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", resp.StatusCode)
+	}
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("attempts = %d, want 1", got)
+	}
+}
+
+func TestTransportBreaker(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if failing.Load() {
+			return respond(req, http.StatusBadGateway), nil
+		}
+		return respond(req, http.StatusOK), nil
+	})
+	policy := fastPolicy()
+	policy.MaxRetries = 0
+	policy.BreakerThreshold = 2
+	policy.BreakerCooldown = 20 * time.Millisecond
+	tr := NewTransport(base, policy, nil)
+
+	for i := 0; i < policy.BreakerThreshold; i++ {
+		if _, err := get(t, tr); err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+	}
+	if _, err := get(t, tr); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("error after %d failures = %v, want ErrCircuitOpen", policy.BreakerThreshold, err)
+	}
+	if got := tr.OpenCircuits(); len(got) != 1 || got[0] != "example.com" {
+		t.Errorf("open circuits = %v, want [example.com]", got)
+	}
+
+	// After the cooldown a failed probe opens the circuit again at once.
+	time.Sleep(policy.BreakerCooldown)
+	if _, err := get(t, tr); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if _, err := get(t, tr); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("error after failed probe = %v, want ErrCircuitOpen", err)
+	}
+
+	// A successful probe closes it.
+	failing.Store(false)
+	time.Sleep(policy.BreakerCooldown)
+	for i := 0; i < 3; i++ {
+		if _, err := get(t, tr); err != nil {
+			t.Fatalf("request %d after recovery: %v", i, err)
+		}
+	}
+	if got := tr.OpenCircuits(); len(got) != 0 {
+		t.Errorf("open circuits = %v, want none", got)
+	}
+}
+
+func TestTransportAttemptTimeout(t *testing.T) {
+	var attempts atomic.Int32
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if attempts.Add(1) == 1 {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		}
+		return respond(req, http.StatusOK), nil
+	})
+	tr := NewTransport(base, fastPolicy(), nil)
+	tr.SetAttemptTimeout(20 * time.Millisecond)
+
+	if _, err := get(t, tr); err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+	if got := attempts.Load(); got != 2 {
+		t.Errorf("attempts = %d, want 2", got)
+	}
+}
+
+func TestTransportRetryAfterOutlastsAttemptTimeout(t *testing.T) {
+	var attempts atomic.Int32
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if attempts.Add(1) == 1 {
+			resp := respond(req, http.StatusTooManyRequests)
+			resp.Header.Set("Retry-After", "1")
+			return resp, nil
+		}
+		return respond(req, http.StatusOK), nil
+	})
+	tr := NewTransport(base, DefaultPolicy(), nil)
+	tr.SetAttemptTimeout(100 * time.Millisecond)
+
+	resp, err := get(t, tr)
+	if err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+}
+
+// failUnlessCancelled answers 502 to requests whose context is live.
+var failUnlessCancelled = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	return respond(req, http.StatusBadGateway), nil
+})
+
+// send makes a request that is cancelled after timeout; a zero timeout
+// cancels it before it is sent.
+func send(tr *Transport, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://example.com/", nil)
+	resp, err := tr.RoundTrip(req)
+	if resp != nil {
+		resp.Body.Close()
+	}
+	return err
+}
+
+func TestTransportCancelledRequestsKeepBreakerClosed(t *testing.T) {
+	policy := Policy{
+		MaxRetries:       3,
+		BaseBackoff:      time.Hour,
+		MaxBackoff:       time.Hour,
+		MaxRetryAfter:    time.Hour,
+		BreakerThreshold: 1,
+		BreakerCooldown:  time.Hour,
+	}
+	tr := NewTransport(failUnlessCancelled, policy, nil)
+
+	if err := send(tr, 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("cancelled request error = %v, want DeadlineExceeded", err)
+	}
+	// Cancelled while waiting to retry a 502.
+	if err := send(tr, 20*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("retrying request error = %v, want DeadlineExceeded", err)
+	}
+	if got := tr.OpenCircuits(); len(got) != 0 {
+		t.Errorf("open circuits = %v, want none", got)
+	}
+}
+
+func TestTransportCancelledProbeReleasesBreaker(t *testing.T) {
+	policy := Policy{
+		BreakerThreshold: 1,
+		BreakerCooldown:  10 * time.Millisecond,
+	}
+	tr := NewTransport(failUnlessCancelled, policy, nil)
+
+	if _, err := get(t, tr); err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+	if got := tr.OpenCircuits(); len(got) != 1 {
+		t.Fatalf("open circuits = %v, want example.com", got)
+	}
+
+	// The half-open probe is cancelled, which leaves no verdict: the next
+	// request probes straight away rather than being refused.
+	time.Sleep(policy.BreakerCooldown)
+	if err := send(tr, 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("probe error = %v, want DeadlineExceeded", err)
+	}
+	if got := tr.OpenCircuits(); len(got) != 0 {
+		t.Errorf("open circuits after cancelled probe = %v, want none", got)
+	}
+	if _, err := get(t, tr); errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("breaker stuck half open: %v", err)
+	}
+	// That probe failed, so the circuit opens again.
+	if got := tr.OpenCircuits(); len(got) != 1 {
+		t.Errorf("open circuits = %v, want example.com", got)
+	}
+}
+
+func TestTransportBackoffIgnoresMaxRetryAfter(t *testing.T) {
+	var attempts atomic.Int32
+	policy := fastPolicy()
+	policy.MaxRetryAfter = time.Nanosecond
+	tr := NewTransport(statuses(&attempts, http.StatusBadGateway, http.StatusOK), policy, nil)
+
+	resp, err := get(t, tr)
+	if err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+	if got := attempts.Load(); got != 2 {
+		t.Errorf("attempts = %d, want 2", got)
+	}
+}
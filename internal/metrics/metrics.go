@@ -0,0 +1,107 @@
+// Package metrics defines the Prometheus collectors shared by the scrapers
+// and watchers and serves them over HTTP.
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "snapprice"
+
+var (
+	// HTTPRequests counts every attempt sent to a host, retries included.
+	// Status is the response code, or "error" when no response came back.
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests sent to retailers, by host and response status.",
+	}, []string{"host", "status"})
+
+	HTTPRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_retries_total",
+		Help:      "HTTP requests retried, by host and failure class.",
+	}, []string{"host", "class"})
+
+	// PagesFetched counts search pages returned by a source, including
+	// pages that failed to parse.
+	PagesFetched = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pages_fetched_total",
+		Help:      "Search result pages fetched, by source.",
+	}, []string{"source"})
+
+	ProductsParsed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "products_parsed_total",
+		Help:      "Products extracted from search pages, by source.",
+	}, []string{"source"})
+
+	ProductsSkipped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "products_skipped_total",
+		Help:      "Products dropped from search pages, by source and reason.",
+	}, []string{"source", "reason"})
+
+	// ItemUpserts counts saved items; created is "true" for new items.
+	ItemUpserts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "item_upserts_total",
+		Help:      "Items saved, by source and whether the item was new.",
+	}, []string{"source", "created"})
+
+	// PriceInserts counts new price points; prices that only extended an
+	// existing point are not counted.
+	PriceInserts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "price_inserts_total",
+		Help:      "Price points written, by source.",
+	}, []string{"source"})
+
+	// DetailsFetched counts product detail pages requested; result is "ok"
+	// or "error".
+	DetailsFetched = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "details_fetched_total",
+		Help:      "Product detail pages fetched, by source and result.",
+	}, []string{"source", "result"})
+
+	DBOperation = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_operation_seconds",
+		Help:      "Latency of database operations, by store and operation.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"store", "op"})
+
+	LastSuccessPage = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "last_success_page_timestamp_seconds",
+		Help:      "Unix time of the last search page parsed successfully, by source.",
+	}, []string{"source"})
+
+	// SourceDegraded is 1 while a source's pages keep failing extraction,
+	// as decided by the engine's drift threshold.
+	SourceDegraded = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "source_degraded",
+		Help:      "Whether a source is degraded by schema drift, by source.",
+	}, []string{"source"})
+)
+
+// ObserveDB records how long a database operation has taken so far. Call
+// it as defer metrics.ObserveDB(store, op)().
+func ObserveDB(store, op string) func() {
+	start := time.Now()
+	return func() {
+		DBOperation.WithLabelValues(store, op).Observe(time.Since(start).Seconds())
+	}
+}
+
+// ItemUpserted counts one saved item.
+func ItemUpserted(source string, created bool) {
+	ItemUpserts.WithLabelValues(source, strconv.FormatBool(created)).Inc()
+}
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const DefaultShutdownTimeout = 5 * time.Second
+
+// Server serves /metrics, plus any handlers added to Mux, until its context
+// is cancelled or Close is called.
+type Server struct {
+	Mux *http.ServeMux
+
+	srv    *http.Server
+	logger *slog.Logger
+	done   chan struct{}
+	once   sync.Once
+}
+
+// Listen binds addr straight away, so a taken port is reported before any
+// work starts, and serves in the background.
+func Listen(ctx context.Context, addr string, logger *slog.Logger) (*Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listen on %s: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	s := &Server{
+		Mux:    mux,
+		srv:    &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second},
+		logger: logger,
+		done:   make(chan struct{}),
+	}
+
+	go func() {
+		if err := s.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("http server", "error", err)
+		}
+	}()
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.Close()
+		case <-s.done:
+		}
+	}()
+	logger.Info("serving http", "addr", ln.Addr().String())
+	return s, nil
+}
+
+// Close stops the server, giving in-flight scrapes a moment to finish.
+func (s *Server) Close() {
+	s.once.Do(func() {
+		close(s.done)
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultShutdownTimeout)
+		defer cancel()
+		if err := s.srv.Shutdown(ctx); err != nil {
+			s.logger.Error("stop http server", "error", err)
+		}
+	})
+}
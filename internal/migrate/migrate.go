@@ -0,0 +1,95 @@
+package migrate
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"github.com/mindsgn-studio/takealot-scraper/internal/store"
+)
+
+// Migrator copies items and prices from one store into another, keeping item
+// IDs so prices still point at the right item.
+type Migrator struct {
+	cfg    model.Config
+	from   store.Store
+	to     store.Store
+	logger *slog.Logger
+}
+
+func New(cfg model.Config, from store.Store, to store.Store, logger *slog.Logger) *Migrator {
+	return &Migrator{
+		cfg:    cfg,
+		from:   from,
+		to:     to,
+		logger: logger,
+	}
+}
+
+func (m *Migrator) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		if err := m.MigrateItems(ctx); err != nil {
+			m.logger.Error("migrate items failed", "error", err)
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		if err := m.MigratePrices(ctx); err != nil {
+			m.logger.Error("migrate prices failed", "error", err)
+		}
+	}()
+
+	wg.Wait()
+}
+
+func (m *Migrator) MigrateItems(ctx context.Context) error {
+	m.logger.Info("migrating items")
+
+	count := 0
+	err := m.from.EachItem(ctx, func(item model.Item) error {
+		if !m.cfg.DryRun {
+			if _, err := m.to.UpsertItem(ctx, &item); err != nil {
+				m.logger.Error("insert item", "item_id", item.ID, "error", err)
+				return nil
+			}
+		}
+
+		count++
+		if count%100 == 0 {
+			m.logger.Info("migrating items", "count", count)
+		}
+		return nil
+	})
+
+	m.logger.Info("migrated items", "count", count)
+	return err
+}
+
+func (m *Migrator) MigratePrices(ctx context.Context) error {
+	m.logger.Info("migrating prices")
+
+	count := 0
+	err := m.from.EachPrice(ctx, func(price model.Price) error {
+		if !m.cfg.DryRun {
+			if err := m.to.AppendPrice(ctx, price); err != nil {
+				m.logger.Error("insert price", "item_id", price.ItemID, "error", err)
+				return nil
+			}
+		}
+
+		count++
+		if count%100 == 0 {
+			m.logger.Info("migrating prices", "count", count)
+		}
+		return nil
+	})
+
+	m.logger.Info("migrated prices", "count", count)
+	return err
+}
@@ -0,0 +1,21 @@
+package model
+
+// Availability is whether a retailer can sell an item, as it says on the
+// listing or product page. The empty value means the page did not say.
+type Availability string
+
+const (
+	AvailabilityUnknown Availability = ""
+	InStock             Availability = "in_stock"
+	LimitedStock        Availability = "limited"
+	OutOfStock          Availability = "out_of_stock"
+	PreOrder            Availability = "pre_order"
+	// LeadTime is stock ordered from a supplier on demand; the price's
+	// LeadTime says how long it takes.
+	LeadTime Availability = "lead_time"
+)
+
+// Available reports whether the item can be ordered for delivery now.
+func (a Availability) Available() bool {
+	return a == InStock || a == LimitedStock || a == LeadTime
+}
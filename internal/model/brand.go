@@ -1,5 +0,0 @@
-package model
-
-type Brand struct {
-	Brand string `bson:"brand"`
-}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// Checkpoint records how far a source's crawl of one keyword got during a
+// run. The checkpoint with an empty Keyword tracks the pass as a whole.
+type Checkpoint struct {
+	Source  string
+	Keyword string
+	RunID   string
+	Cursor  string
+	Page    int
+	Done    bool
+	Updated time.Time
+}
@@ -1,10 +1,64 @@
 package model
 
+import "time"
+
 type Config struct {
-	MongoURI   string
-	DBName     string
-	ItemsColl  string
-	PricesColl string
-	BrandFile  string
-	UserAgent  string
+	MongoURI        string
+	PostgresURI     string
+	DBName          string
+	ItemsColl       string
+	PricesColl      string
+	WatchesColl     string
+	LeasesColl      string
+	CheckpointsColl string
+	QuarantineColl  string
+	RunsColl        string
+	SchedulesColl   string
+	RecrawlColl     string
+	KeywordsColl    string
+	DetailsColl     string
+	// DBOpTimeout bounds each Mongo or Postgres operation.
+	DBOpTimeout time.Duration
+	BrandFile   string
+	// UserAgent is sent by sources that do not set their own.
+	UserAgent string
+	LogLevel  string
+	LogFormat string
+	DryRun    bool
+
+	// HTTP is the retry and circuit breaker policy of every source.
+	HTTP HTTPConfig
+	// Sources holds each retailer's settings by name.
+	Sources map[string]SourceConfig
+}
+
+// Source returns the settings of the named retailer, falling back to the
+// global user agent.
+func (c Config) Source(name string) SourceConfig {
+	s := c.Sources[name]
+	if s.UserAgent == "" {
+		s.UserAgent = c.UserAgent
+	}
+	return s
+}
+
+// HTTPConfig mirrors httpx.Policy.
+type HTTPConfig struct {
+	MaxRetries       int
+	BaseBackoff      time.Duration
+	MaxBackoff       time.Duration
+	MaxRetryAfter    time.Duration
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+// SourceConfig tunes how one retailer is crawled.
+type SourceConfig struct {
+	// UserAgent is empty when the source sends the global one.
+	UserAgent         string
+	RequestsPerSecond float64
+	// Timeout bounds each attempt at a request; retries and the waits
+	// between them are not counted against it.
+	Timeout          time.Duration
+	PriceDedupWindow time.Duration
 }
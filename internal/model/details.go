@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// Details is what a retailer's product page says about an item beyond its
+// search listing.
+type Details struct {
+	ItemID      string
+	Source      string
+	SourceID    string
+	Description string
+	// Specs are the product's specification rows by name.
+	Specs map[string]string
+	// Categories is the breadcrumb trail, broadest first.
+	Categories []string
+	// Barcodes are the product's GTINs (EAN, UPC and the like).
+	Barcodes []string
+	Warranty string
+	// Rating is the average star rating out of five; Reviews is how many
+	// reviews it is the average of.
+	Rating  float64
+	Reviews int
+	Fetched time.Time
+}
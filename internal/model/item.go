@@ -1,17 +1,20 @@
 package model
 
-import (
-	"time"
-
-	"go.mongodb.org/mongo-driver/bson/primitive"
-)
+import "time"
 
+// Item is a product listed by a single retailer. ID is assigned by the store;
+// SourceID is the retailer's own identifier (PLID, ASIN, product code).
+// Availability is the latest known stock state; the price history keeps
+// earlier ones.
 type Item struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty"`
-	Title     string             `bson:"title"`
-	Images    []string           `bson:"images"`
-	Link      string             `bson:"link"`
-	Brand     string             `bson:"brand"`
-	Source    string             `bson:"source"`
-	UpdatedAt time.Time          `bson:"updated_at"`
+	ID           string
+	Source       string
+	SourceID     string
+	Title        string
+	Images       []string
+	Link         string
+	Brand        string
+	Availability Availability
+	Created      time.Time
+	Updated      time.Time
 }
@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// Keyword is a search term in the crawl catalogue.
+type Keyword struct {
+	Keyword  string
+	Category string
+	Enabled  bool
+	// Sources lists the retailers the keyword is searched on; empty means
+	// every retailer.
+	Sources []string
+	AddedBy string
+	Added   time.Time
+	// Stats holds the crawl yield by source.
+	Stats map[string]KeywordStats
+}
+
+// AppliesTo reports whether the keyword should be searched on source.
+func (k Keyword) AppliesTo(source string) bool {
+	if len(k.Sources) == 0 {
+		return true
+	}
+	for _, s := range k.Sources {
+		if s == source {
+			return true
+		}
+	}
+	return false
+}
+
+// KeywordStats is what crawling a keyword on one source has yielded.
+type KeywordStats struct {
+	LastCrawled time.Time
+	Crawls      int64
+	Pages       int64
+	Listings    int64
+	NewItems    int64
+}
@@ -1,15 +1,39 @@
 package model
 
 import (
+	"math"
 	"time"
-
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Price is a point in an item's price history. Date is when the price was
+// first seen; LastSeen moves forward while later sightings find it unchanged.
+// Price is the selling price. ListPrice is the was or list price it is
+// discounted from, zero when the retailer shows none; Savings and
+// DiscountPct follow from the two. Availability is the stock state seen with
+// the price, and LeadTime the retailer's delivery estimate when it is
+// LeadTime.
 type Price struct {
-	ID       primitive.ObjectID `bson:"_id,omitempty"`
-	ItemID   primitive.ObjectID `bson:"itemID"`
-	Date     time.Time          `bson:"date"`
-	Currency string             `bson:"currency"`
-	Price    float64            `bson:"price"`
+	ItemID       string
+	Date         time.Time
+	LastSeen     time.Time
+	Currency     string
+	Price        float64
+	ListPrice    float64
+	Savings      float64
+	DiscountPct  float64
+	Availability Availability
+	LeadTime     string
+}
+
+// SetListPrice records list as the price p is discounted from and works out
+// the savings. A list price not above the selling price is no discount and
+// clears all three.
+func (p *Price) SetListPrice(list float64) {
+	if list <= p.Price || p.Price <= 0 {
+		p.ListPrice, p.Savings, p.DiscountPct = 0, 0, 0
+		return
+	}
+	p.ListPrice = list
+	p.Savings = math.Round((list-p.Price)*100) / 100
+	p.DiscountPct = math.Round(p.Savings/list*10000) / 100
 }
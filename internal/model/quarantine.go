@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// Quarantined is a page or product a source could not extract, kept with a
+// trimmed sample of the raw payload so schema changes can be diagnosed.
+// ProductID is empty when the whole page failed.
+type Quarantined struct {
+	Source    string
+	Keyword   string
+	Page      int
+	Cursor    string
+	ProductID string
+	Reason    string
+	Sample    string
+	Created   time.Time
+}
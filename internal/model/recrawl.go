@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// Kinds of work a Recrawl paces.
+const (
+	RecrawlKeyword = "keyword"
+	RecrawlItem    = "item"
+)
+
+// Recrawl is how often a keyword or item is worth revisiting, learned from
+// whether its prices changed on recent visits. Key is the keyword, or the
+// item ID.
+type Recrawl struct {
+	Kind        string
+	Source      string
+	Key         string
+	Interval    time.Duration
+	NextDue     time.Time
+	LastChanged time.Time
+	Updated     time.Time
+}
@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// Exit reasons recorded on a Run.
+const (
+	RunRunning   = "running"
+	RunCompleted = "completed"
+	RunCancelled = "cancelled"
+	RunFailed    = "failed"
+)
+
+// Run summarises one process's pass over a source's keywords. Pass is the
+// checkpoint run it worked on, which other workers and resumed processes
+// share; ID is unique to this process's attempt.
+type Run struct {
+	ID       string
+	Pass     string
+	Source   string
+	Host     string
+	WorkerID string
+	Started  time.Time
+	Finished time.Time
+
+	Keywords     int64
+	Pages        int64
+	ItemsNew     int64
+	ItemsUpdated int64
+	Prices       int64
+	// Errors counts failures by category, such as fetch or persist.
+	Errors map[string]int64
+
+	Exit  string
+	Error string
+}
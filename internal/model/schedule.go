@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// Schedule is the persisted state of a recurring daemon job, so a restart
+// picks up where the timetable left off.
+type Schedule struct {
+	Name         string
+	LastStarted  time.Time
+	LastFinished time.Time
+	LastError    string
+	NextDue      time.Time
+}
@@ -0,0 +1,9 @@
+package model
+
+import "time"
+
+type Stats struct {
+	ItemsBySource map[string]int64
+	Prices        int64
+	LatestPrice   time.Time
+}
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// Watch is a user or device following an item's price.
+type Watch struct {
+	ItemID  string
+	User    string
+	Token   string
+	Device  string
+	Created time.Time
+}
@@ -0,0 +1,178 @@
+// Package priority scores keywords and items so the work people care about
+// is refreshed first: watched items, items whose prices keep moving, and
+// anything that has not been seen for a while.
+package priority
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"github.com/mindsgn-studio/takealot-scraper/internal/store"
+)
+
+const (
+	// DefaultWindow is how far back price changes are counted.
+	DefaultWindow = 30 * 24 * time.Hour
+	// MaxStaleness caps how much credit time since last seen can earn, and
+	// is what something never seen is treated as.
+	MaxStaleness = 30 * 24 * time.Hour
+)
+
+// Weights turn Signals into a score.
+type Weights struct {
+	// Watch is added per watch on an item.
+	Watch float64
+	// Change is added per price change inside the window.
+	Change float64
+	// Staleness is added per day since last seen.
+	Staleness float64
+}
+
+func DefaultWeights() Weights {
+	return Weights{Watch: 10, Change: 2, Staleness: 1}
+}
+
+// Signals are what a score is made of.
+type Signals struct {
+	Watches  int
+	Changes  int
+	LastSeen time.Time
+}
+
+func (s Signals) Score(now time.Time, w Weights) float64 {
+	stale := MaxStaleness
+	if !s.LastSeen.IsZero() {
+		stale = min(max(now.Sub(s.LastSeen), 0), MaxStaleness)
+	}
+	days := stale.Hours() / 24
+	return w.Watch*float64(s.Watches) + w.Change*float64(s.Changes) + w.Staleness*days
+}
+
+// merge folds o into s as a keyword aggregates its items: watches and
+// changes add up, and the keyword was seen when any of its items was.
+func (s Signals) merge(o Signals) Signals {
+	s.Watches += o.Watches
+	s.Changes += o.Changes
+	if o.LastSeen.After(s.LastSeen) {
+		s.LastSeen = o.LastSeen
+	}
+	return s
+}
+
+// Table holds the signals of every item and of every brand, by source.
+type Table struct {
+	Items map[string]ItemSignals
+	// brands maps source and lowercased brand to the merged signals of
+	// the brand's items.
+	brands map[string]Signals
+}
+
+type ItemSignals struct {
+	Source string
+	Signals
+}
+
+func brandKey(source, brand string) string {
+	return source + "\x00" + strings.ToLower(strings.TrimSpace(brand))
+}
+
+// Brand returns the merged signals of source's items of brand.
+func (t Table) Brand(source, brand string) (Signals, bool) {
+	s, ok := t.brands[brandKey(source, brand)]
+	return s, ok
+}
+
+// Collect reads watches, items and the prices dated or last seen since
+// now-window from st. Older points are not read: with a window of at least
+// MaxStaleness, a sighting before it scores the same as none.
+func Collect(ctx context.Context, st store.Store, now time.Time, window time.Duration) (Table, error) {
+	t := Table{
+		Items:  make(map[string]ItemSignals),
+		brands: make(map[string]Signals),
+	}
+
+	watches, err := st.ListWatches(ctx)
+	if err != nil {
+		return Table{}, fmt.Errorf("list watches: %w", err)
+	}
+	watchCount := make(map[string]int)
+	for _, w := range watches {
+		watchCount[w.ItemID]++
+	}
+
+	since := now.Add(-window)
+	recent := make(map[string][]model.Price)
+	lastSeen := make(map[string]time.Time)
+	err = st.EachPriceSince(ctx, since, func(p model.Price) error {
+		seen := p.LastSeen
+		if seen.IsZero() {
+			seen = p.Date
+		}
+		if seen.After(lastSeen[p.ItemID]) {
+			lastSeen[p.ItemID] = seen
+		}
+		if !p.Date.Before(since) {
+			recent[p.ItemID] = append(recent[p.ItemID], p)
+		}
+		return nil
+	})
+	if err != nil {
+		return Table{}, fmt.Errorf("read prices: %w", err)
+	}
+
+	brandOf := make(map[string]string)
+	err = st.EachItem(ctx, func(item model.Item) error {
+		s := Signals{
+			Watches:  watchCount[item.ID],
+			Changes:  changes(recent[item.ID]),
+			LastSeen: item.Updated,
+		}
+		if seen := lastSeen[item.ID]; seen.After(s.LastSeen) {
+			s.LastSeen = seen
+		}
+		t.Items[item.ID] = ItemSignals{Source: item.Source, Signals: s}
+		brandOf[item.ID] = brandKey(item.Source, item.Brand)
+		return nil
+	})
+	if err != nil {
+		return Table{}, fmt.Errorf("read items: %w", err)
+	}
+
+	for id, key := range brandOf {
+		t.brands[key] = t.brands[key].merge(t.Items[id].Signals)
+	}
+	return t, nil
+}
+
+// changes counts how often consecutive points differ in price.
+func changes(prices []model.Price) int {
+	sort.Slice(prices, func(i, j int) bool { return prices[i].Date.Before(prices[j].Date) })
+	n := 0
+	for i := 1; i < len(prices); i++ {
+		if prices[i].Price != prices[i-1].Price {
+			n++
+		}
+	}
+	return n
+}
+
+// Ranked is a keyword or item ID with its score.
+type Ranked struct {
+	Key   string
+	Score float64
+}
+
+// Rank orders keys by score, highest first, keeping the incoming order for
+// ties so a shuffled list stays shuffled within a score.
+func Rank(keys []string, signals func(key string) Signals, now time.Time, w Weights) []Ranked {
+	ranked := make([]Ranked, len(keys))
+	for i, key := range keys {
+		ranked[i] = Ranked{Key: key, Score: signals(key).Score(now, w)}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Score > ranked[j].Score })
+	return ranked
+}
@@ -0,0 +1,85 @@
+package priority
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"github.com/mindsgn-studio/takealot-scraper/internal/store"
+)
+
+func TestCollect(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	st := store.NewMemory()
+
+	item := model.Item{Source: "takealot", SourceID: "1", Brand: "Defy", Updated: now.Add(-40 * day)}
+	if _, err := st.UpsertItem(ctx, &item); err != nil {
+		t.Fatal(err)
+	}
+	other := model.Item{Source: "takealot", SourceID: "2", Brand: "defy ", Updated: now.Add(-day)}
+	if _, err := st.UpsertItem(ctx, &other); err != nil {
+		t.Fatal(err)
+	}
+	st.AddWatch(model.Watch{ItemID: item.ID})
+
+	points := []model.Price{
+		// Outside the window: neither its change nor its date count.
+		{ItemID: item.ID, Date: now.Add(-60 * day), LastSeen: now.Add(-50 * day), Price: 10},
+		{ItemID: item.ID, Date: now.Add(-45 * day), LastSeen: now.Add(-45 * day), Price: 12},
+		// Dated before the window but seen inside it.
+		{ItemID: item.ID, Date: now.Add(-35 * day), LastSeen: now.Add(-3 * day), Price: 11},
+		{ItemID: item.ID, Date: now.Add(-2 * day), LastSeen: now.Add(-2 * day), Price: 9},
+		{ItemID: item.ID, Date: now.Add(-day), LastSeen: now.Add(-day / 2), Price: 8},
+	}
+	for _, p := range points {
+		if err := st.AppendPrice(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	table, err := Collect(ctx, st, now, DefaultWindow)
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+
+	got := table.Items[item.ID]
+	want := Signals{Watches: 1, Changes: 1, LastSeen: now.Add(-day / 2)}
+	if got.Signals != want || got.Source != "takealot" {
+		t.Errorf("item signals = %+v, want %+v", got, want)
+	}
+
+	brand, ok := table.Brand("takealot", "DEFY")
+	if !ok {
+		t.Fatal("no signals for brand")
+	}
+	want = Signals{Watches: 1, Changes: 1, LastSeen: now.Add(-day / 2)}
+	if brand != want {
+		t.Errorf("brand signals = %+v, want %+v", brand, want)
+	}
+}
+
+func TestScore(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	w := DefaultWeights()
+	tests := []struct {
+		name string
+		s    Signals
+		want float64
+	}{
+		{"never seen", Signals{}, 30},
+		{"seen now", Signals{LastSeen: now}, 0},
+		{"seen in the future", Signals{LastSeen: now.Add(time.Hour)}, 0},
+		{"stale cap", Signals{LastSeen: now.Add(-90 * 24 * time.Hour)}, 30},
+		{"watched and moving", Signals{Watches: 2, Changes: 3, LastSeen: now.Add(-48 * time.Hour)}, 20 + 6 + 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.s.Score(now, w); got != tt.want {
+				t.Errorf("Score = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+// Package recrawl learns how often a keyword or item is worth revisiting.
+// Every visit that finds a changed price halves the interval, down to Min;
+// every visit that finds nothing new doubles it, up to Max.
+package recrawl
+
+import (
+	"sort"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+)
+
+const (
+	DefaultMin     = 6 * time.Hour
+	DefaultMax     = 90 * 24 * time.Hour
+	DefaultInitial = 24 * time.Hour
+)
+
+// Policy bounds the intervals. The zero Policy is off: everything is
+// always due.
+type Policy struct {
+	Min time.Duration
+	Max time.Duration
+	// Initial is the interval for something with no record and no price
+	// history to learn from.
+	Initial time.Duration
+}
+
+func DefaultPolicy() Policy {
+	return Policy{Min: DefaultMin, Max: DefaultMax, Initial: DefaultInitial}
+}
+
+func (p Policy) Enabled() bool {
+	return p.Max > 0
+}
+
+// Due reports whether r's next visit has come. Anything without a record
+// is due.
+func Due(r model.Recrawl, ok bool, now time.Time) bool {
+	return !ok || !now.Before(r.NextDue)
+}
+
+// Next returns r updated for a visit at now that did or did not find a
+// changed price. A record without an interval starts from seed.
+func (p Policy) Next(r model.Recrawl, changed bool, seed time.Duration, now time.Time) model.Recrawl {
+	interval := r.Interval
+	if interval <= 0 {
+		interval = seed
+	} else if changed {
+		interval /= 2
+	} else {
+		interval *= 2
+	}
+	r.Interval = p.clamp(interval)
+	if changed {
+		r.LastChanged = now
+	}
+	r.NextDue = now.Add(r.Interval)
+	r.Updated = now
+	return r
+}
+
+// Seed estimates a first interval from an item's price history: half the
+// average time between changes, or Initial when the price never changed.
+func (p Policy) Seed(history []model.Price) time.Duration {
+	sort.Slice(history, func(i, j int) bool { return history[i].Date.Before(history[j].Date) })
+
+	var changes []time.Time
+	for i := 1; i < len(history); i++ {
+		if history[i].Price != history[i-1].Price {
+			changes = append(changes, history[i].Date)
+		}
+	}
+	if len(changes) == 0 {
+		return p.clamp(p.Initial)
+	}
+	span := changes[len(changes)-1].Sub(history[0].Date)
+	return p.clamp(span / time.Duration(len(changes)) / 2)
+}
+
+// SeedKeyword estimates a first interval for a keyword from the price
+// histories of the items it returns. A keyword sees a change whenever any
+// of its items does, so it takes the shortest of their seeds, or Initial
+// when it has no items.
+func (p Policy) SeedKeyword(histories [][]model.Price) time.Duration {
+	if len(histories) == 0 {
+		return p.clamp(p.Initial)
+	}
+	seed := p.Max
+	for _, history := range histories {
+		seed = min(seed, p.Seed(history))
+	}
+	return seed
+}
+
+func (p Policy) clamp(d time.Duration) time.Duration {
+	if d < p.Min {
+		d = p.Min
+	}
+	if d > p.Max {
+		d = p.Max
+	}
+	return d
+}
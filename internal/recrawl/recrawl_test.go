@@ -0,0 +1,104 @@
+package recrawl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+)
+
+// history returns points a day apart with the given prices.
+func history(prices ...float64) []model.Price {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	out := make([]model.Price, len(prices))
+	for i, p := range prices {
+		out[i] = model.Price{Date: start.Add(time.Duration(i) * 24 * time.Hour), Price: p}
+	}
+	return out
+}
+
+func TestSeed(t *testing.T) {
+	p := DefaultPolicy()
+	tests := []struct {
+		name    string
+		history []model.Price
+		want    time.Duration
+	}{
+		{"no history", nil, DefaultInitial},
+		{"never changed", history(10, 10, 10), DefaultInitial},
+		{"daily changes", history(10, 11, 12, 13), 12 * time.Hour},
+		{"one change in four days", history(10, 10, 10, 10, 12), 48 * time.Hour},
+		{"single change", history(10, 11), 12 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.Seed(tt.history); got != tt.want {
+				t.Errorf("Seed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	p := DefaultPolicy()
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		interval time.Duration
+		changed  bool
+		want     time.Duration
+	}{
+		{"new record starts from seed", 0, false, 36 * time.Hour},
+		{"change halves", 48 * time.Hour, true, 24 * time.Hour},
+		{"no change doubles", 48 * time.Hour, false, 96 * time.Hour},
+		{"clamped to min", DefaultMin, true, DefaultMin},
+		{"clamped to max", DefaultMax, false, DefaultMax},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := p.Next(model.Recrawl{Interval: tt.interval}, tt.changed, 36*time.Hour, now)
+			if r.Interval != tt.want {
+				t.Errorf("Interval = %v, want %v", r.Interval, tt.want)
+			}
+			if !r.NextDue.Equal(now.Add(tt.want)) {
+				t.Errorf("NextDue = %v, want %v", r.NextDue, now.Add(tt.want))
+			}
+			if r.LastChanged.IsZero() == tt.changed {
+				t.Errorf("LastChanged = %v after changed = %v", r.LastChanged, tt.changed)
+			}
+		})
+	}
+}
+
+func TestDue(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	if !Due(model.Recrawl{}, false, now) {
+		t.Error("keyword without a record is not due")
+	}
+	if Due(model.Recrawl{NextDue: now.Add(time.Minute)}, true, now) {
+		t.Error("keyword is due before NextDue")
+	}
+	if !Due(model.Recrawl{NextDue: now}, true, now) {
+		t.Error("keyword is not due at NextDue")
+	}
+}
+
+func TestSeedKeyword(t *testing.T) {
+	p := DefaultPolicy()
+	tests := []struct {
+		name      string
+		histories [][]model.Price
+		want      time.Duration
+	}{
+		{"no items", nil, DefaultInitial},
+		{"items never changed", [][]model.Price{history(10, 10), history(5)}, DefaultInitial},
+		{"most volatile item wins", [][]model.Price{history(10, 10, 10, 10, 12), history(10, 11, 12, 13), history(7)}, 12 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.SeedKeyword(tt.histories); got != tt.want {
+				t.Errorf("SeedKeyword = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
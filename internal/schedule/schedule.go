@@ -0,0 +1,163 @@
+// Package schedule runs recurring jobs on their own cadences for the
+// daemon, remembering when each last ran so a restart does not start every
+// job over.
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"github.com/mindsgn-studio/takealot-scraper/internal/store"
+)
+
+// Job is one recurring piece of work. Each job runs on its own goroutine,
+// so a run that outlasts Every delays the next run rather than overlapping
+// it.
+type Job struct {
+	Name  string
+	Every time.Duration
+	// Jitter is the most that is randomly added to each due time, so jobs
+	// sharing a cadence do not start in lockstep.
+	Jitter time.Duration
+	Run    func(ctx context.Context) error
+}
+
+// Scheduler runs jobs until its context is cancelled. Job timing is
+// persisted through a store.Timetable when one is given.
+type Scheduler struct {
+	timetable store.Timetable
+	logger    *slog.Logger
+	jobs      []Job
+}
+
+// New returns a Scheduler. timetable may be nil, in which case every job
+// is due as soon as the scheduler starts.
+func New(timetable store.Timetable, logger *slog.Logger) *Scheduler {
+	return &Scheduler{
+		timetable: timetable,
+		logger:    logger,
+	}
+}
+
+func (s *Scheduler) Add(job Job) error {
+	if job.Every <= 0 {
+		return fmt.Errorf("job %s: interval must be positive", job.Name)
+	}
+	for _, j := range s.jobs {
+		if j.Name == job.Name {
+			return fmt.Errorf("job %s added twice", job.Name)
+		}
+	}
+	s.jobs = append(s.jobs, job)
+	return nil
+}
+
+// Run blocks until ctx is cancelled and every job in progress has returned.
+func (s *Scheduler) Run(ctx context.Context) error {
+	if len(s.jobs) == 0 {
+		return errors.New("no jobs scheduled")
+	}
+
+	var wg sync.WaitGroup
+	for _, job := range s.jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.loop(ctx, job)
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	log := s.logger.With("job", job.Name)
+	state := s.load(ctx, job)
+	for {
+		if wait := time.Until(state.NextDue); wait > 0 {
+			log.Info("next run scheduled", "due", state.NextDue.Format(time.RFC3339), "in", wait.Round(time.Second))
+			t := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return
+			case <-t.C:
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		state.LastStarted = time.Now().UTC()
+		s.save(ctx, state)
+		log.Info("starting job")
+
+		err := job.Run(ctx)
+
+		state.LastFinished = time.Now().UTC()
+		state.LastError = ""
+		if err != nil && !errors.Is(err, context.Canceled) {
+			state.LastError = err.Error()
+			log.Error("job failed", "error", err, "duration", state.LastFinished.Sub(state.LastStarted).Round(time.Second))
+		} else {
+			log.Info("job finished", "duration", state.LastFinished.Sub(state.LastStarted).Round(time.Second))
+		}
+		if err != nil && ctx.Err() != nil {
+			// Leave the run looking unfinished so the next start picks
+			// it straight back up.
+			return
+		}
+		state.NextDue = state.LastFinished.Add(job.Every + jitter(job.Jitter))
+		s.save(ctx, state)
+	}
+}
+
+// load returns the job's persisted state with NextDue filled in. A job
+// that never ran, or was interrupted mid-run, is due now; an interval
+// shortened since the last run takes effect straight away.
+func (s *Scheduler) load(ctx context.Context, job Job) model.Schedule {
+	now := time.Now().UTC()
+	fresh := model.Schedule{Name: job.Name, NextDue: now.Add(jitter(job.Jitter))}
+	if s.timetable == nil {
+		return fresh
+	}
+
+	state, err := s.timetable.Schedule(ctx, job.Name)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			s.logger.Error("load schedule", "job", job.Name, "error", err)
+		}
+		return fresh
+	}
+	if state.LastFinished.Before(state.LastStarted) {
+		s.logger.Info("resuming interrupted job", "job", job.Name, "started", state.LastStarted.Format(time.RFC3339))
+		state.NextDue = now
+		return state
+	}
+	if latest := state.LastFinished.Add(job.Every + job.Jitter); state.NextDue.IsZero() || state.NextDue.After(latest) {
+		state.NextDue = state.LastFinished.Add(job.Every + jitter(job.Jitter))
+	}
+	return state
+}
+
+func (s *Scheduler) save(ctx context.Context, state model.Schedule) {
+	if s.timetable == nil {
+		return
+	}
+	if err := s.timetable.SaveSchedule(context.WithoutCancel(ctx), state); err != nil {
+		s.logger.Error("save schedule", "job", state.Name, "error", err)
+	}
+}
+
+func jitter(limit time.Duration) time.Duration {
+	if limit <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(limit)))
+}
@@ -0,0 +1,42 @@
+package scraper
+
+import (
+	"context"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/archive"
+)
+
+func (e *Engine) archiving() bool {
+	return e.opts.Archive != nil && !e.cfg.DryRun
+}
+
+// pruneArchive applies the retention policy before a pass starts.
+func (e *Engine) pruneArchive(ctx context.Context) {
+	if !e.archiving() || e.opts.ArchiveRetention <= 0 {
+		return
+	}
+	removed, err := e.opts.Archive.Prune(ctx, time.Now().Add(-e.opts.ArchiveRetention))
+	if err != nil {
+		e.logger.Error("prune archive", "error", err)
+		return
+	}
+	if removed > 0 {
+		e.logger.Info("pruned archive", "removed", removed, "retention", e.opts.ArchiveRetention)
+	}
+}
+
+func (e *Engine) archivePage(ctx context.Context, keyword string, page int, raw []byte) {
+	if !e.archiving() || len(raw) == 0 {
+		return
+	}
+	entry := archive.Entry{
+		Source:   e.source.Name(),
+		Keyword:  keyword,
+		Page:     page,
+		Captured: time.Now().UTC(),
+	}
+	if err := e.opts.Archive.Put(ctx, entry, raw); err != nil {
+		e.logger.Error("archive page", "keyword", keyword, "page", page, "error", err)
+	}
+}
@@ -0,0 +1,41 @@
+package scraper
+
+import (
+	"strings"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+)
+
+// ParseAvailability reads a retailer's stock text, such as "Only 3 left in
+// stock" or "Ships in 5 - 7 work days". Stock on hand wins over a delivery
+// estimate in the same text. The lead time is the text itself and is set
+// only for model.LeadTime. Text it does not recognise is
+// model.AvailabilityUnknown.
+func ParseAvailability(text string) (model.Availability, string) {
+	text = strings.Join(strings.Fields(text), " ")
+	lower := strings.ToLower(text)
+	switch {
+	case lower == "":
+		return model.AvailabilityUnknown, ""
+	case containsAny(lower, "pre-order", "preorder", "pre order"):
+		return model.PreOrder, ""
+	case containsAny(lower, "out of stock", "unavailable", "sold out", "no stock"):
+		return model.OutOfStock, ""
+	case containsAny(lower, "limited", "left in stock", "low stock"):
+		return model.LimitedStock, ""
+	case strings.Contains(lower, "in stock"):
+		return model.InStock, ""
+	case containsAny(lower, "ships in", "ships within", "usually ships", "dispatched in", "lead time"):
+		return model.LeadTime, strings.TrimSuffix(text, ".")
+	}
+	return model.AvailabilityUnknown, ""
+}
+
+func containsAny(s string, subs ...string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package scraper
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"github.com/mindsgn-studio/takealot-scraper/internal/store"
+)
+
+// passKeyword is the checkpoint keyword that tracks a whole pass.
+const passKeyword = ""
+
+func newRunID() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return time.Now().UTC().Format("20060102T150405") + "-" + hex.EncodeToString(b)
+}
+
+// startPass resumes the source's unfinished pass if there is one, otherwise
+// it starts a new one.
+func (e *Engine) startPass(ctx context.Context) string {
+	if e.checkpoints == nil {
+		return newRunID()
+	}
+
+	cp, err := e.checkpoints.Checkpoint(ctx, e.source.Name(), passKeyword)
+	if err == nil && !cp.Done && cp.RunID != "" {
+		e.logger.Info("resuming unfinished pass", "run_id", cp.RunID, "updated", cp.Updated)
+		return cp.RunID
+	}
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		e.logger.Error("load pass checkpoint", "error", err)
+	}
+
+	runID := newRunID()
+	e.saveCheckpoint(ctx, model.Checkpoint{Keyword: passKeyword, RunID: runID})
+	return runID
+}
+
+func (e *Engine) finishPass(ctx context.Context) {
+	e.saveCheckpoint(ctx, model.Checkpoint{Keyword: passKeyword, RunID: e.runID, Done: true})
+}
+
+// resume returns where keyword's crawl should start in the current run.
+func (e *Engine) resume(ctx context.Context, keyword string) model.Checkpoint {
+	start := model.Checkpoint{Keyword: keyword, RunID: e.runID, Page: 1}
+	if e.checkpoints == nil {
+		return start
+	}
+
+	cp, err := e.checkpoints.Checkpoint(ctx, e.source.Name(), keyword)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			e.logger.Error("load checkpoint", "keyword", keyword, "error", err)
+		}
+		return start
+	}
+	if cp.RunID != e.runID || (!cp.Done && cp.Cursor == "") {
+		return start
+	}
+	return cp
+}
+
+func (e *Engine) saveCheckpoint(ctx context.Context, cp model.Checkpoint) {
+	if e.checkpoints == nil || e.cfg.DryRun {
+		return
+	}
+	cp.Source = e.source.Name()
+	cp.Updated = time.Now().UTC()
+	if err := e.checkpoints.SaveCheckpoint(ctx, cp); err != nil {
+		e.logger.Error("save checkpoint", "keyword", cp.Keyword, "error", err)
+		e.tally.fail(ErrorCheckpoint)
+	}
+}
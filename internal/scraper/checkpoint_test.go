@@ -0,0 +1,115 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"github.com/mindsgn-studio/takealot-scraper/internal/store"
+)
+
+func saveCheckpoints(t *testing.T, st store.Checkpointer, cps ...model.Checkpoint) {
+	t.Helper()
+	for _, cp := range cps {
+		cp.Source = "fake"
+		if err := st.SaveCheckpoint(context.Background(), cp); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func checkpoint(t *testing.T, st store.Checkpointer, keyword string) model.Checkpoint {
+	t.Helper()
+	cp, err := st.Checkpoint(context.Background(), "fake", keyword)
+	if err != nil {
+		t.Fatalf("Checkpoint(%q): %v", keyword, err)
+	}
+	return cp
+}
+
+func TestRunResumesUnfinishedPass(t *testing.T) {
+	st := store.NewMemory()
+	saveCheckpoints(t, st,
+		model.Checkpoint{Keyword: passKeyword, RunID: "run-1"},
+		model.Checkpoint{Keyword: "kettle", RunID: "run-1", Cursor: "2", Page: 2},
+		model.Checkpoint{Keyword: "toaster", RunID: "run-1", Page: 1, Done: true},
+		// Left over from an earlier pass, so crawled again from the start.
+		model.Checkpoint{Keyword: "blender", RunID: "run-0", Cursor: "3", Page: 3},
+	)
+	three := pages(nil, nil, nil)
+	src := &fakeSource{pages: map[string][]Page{"kettle": three, "toaster": three, "blender": three}}
+	e := newTestEngine(t, model.Config{}, src, Options{}, st, "kettle", "toaster", "blender")
+
+	if err := e.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	want := []string{"blender@", "blender@2", "blender@3", "kettle@2", "kettle@3"}
+	if got := src.Calls(); !slices.Equal(got, want) {
+		t.Errorf("searches = %v; want %v", got, want)
+	}
+	if cp := checkpoint(t, st, passKeyword); cp.RunID != "run-1" || !cp.Done {
+		t.Errorf("pass checkpoint = %+v; want run-1 done", cp)
+	}
+	for _, keyword := range []string{"kettle", "blender"} {
+		if cp := checkpoint(t, st, keyword); cp.RunID != "run-1" || !cp.Done || cp.Page != 3 {
+			t.Errorf("%s checkpoint = %+v; want run-1 done on page 3", keyword, cp)
+		}
+	}
+}
+
+func TestRunStartsNewPass(t *testing.T) {
+	st := store.NewMemory()
+	saveCheckpoints(t, st,
+		model.Checkpoint{Keyword: passKeyword, RunID: "run-1", Done: true},
+		model.Checkpoint{Keyword: "kettle", RunID: "run-1", Page: 1, Done: true},
+	)
+	src := &fakeSource{}
+	e := newTestEngine(t, model.Config{}, src, Options{}, st, "kettle")
+
+	if err := e.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got, want := src.Calls(), []string{"kettle@"}; !slices.Equal(got, want) {
+		t.Errorf("searches = %v; want %v", got, want)
+	}
+	if cp := checkpoint(t, st, passKeyword); cp.RunID == "run-1" || !cp.Done {
+		t.Errorf("pass checkpoint = %+v; want a new run, done", cp)
+	}
+}
+
+func TestScrapeKeywordCheckpointsCursor(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemory()
+	fail := errors.New("connection reset")
+	src := &fakeSource{
+		pages: map[string][]Page{"kettle": pages(nil, nil, nil)},
+		errs:  map[string]error{"kettle@3": fail},
+	}
+	e := newTestEngine(t, model.Config{}, src, Options{}, st)
+	e.runID = "run-1"
+
+	if err := e.ScrapeKeyword(ctx, "kettle"); !errors.Is(err, fail) {
+		t.Fatalf("ScrapeKeyword = %v; want %v", err, fail)
+	}
+	if cp := checkpoint(t, st, "kettle"); cp.Cursor != "3" || cp.Page != 3 || cp.Done {
+		t.Errorf("checkpoint = %+v; want page 3 at cursor 3", cp)
+	}
+
+	// The next attempt in the same run picks up from the failed page.
+	delete(src.errs, "kettle@3")
+	if err := e.ScrapeKeyword(ctx, "kettle"); err != nil {
+		t.Fatalf("ScrapeKeyword: %v", err)
+	}
+	want := []string{"kettle@", "kettle@2", "kettle@3", "kettle@3"}
+	if got := src.Calls(); !slices.Equal(got, want) {
+		t.Errorf("searches = %v; want %v", got, want)
+	}
+	if err := e.ScrapeKeyword(ctx, "kettle"); err != nil {
+		t.Fatalf("ScrapeKeyword: %v", err)
+	}
+	if got := src.Calls(); len(got) != len(want) {
+		t.Errorf("finished keyword searched again: %v", got)
+	}
+}
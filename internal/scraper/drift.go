@@ -0,0 +1,102 @@
+package scraper
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/metrics"
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+)
+
+// DefaultDriftThreshold is how many drifted pages in a row mark a source as
+// degraded.
+const DefaultDriftThreshold = 5
+
+// DriftStats counts extraction failures for one source since the process
+// started.
+type DriftStats struct {
+	// Pages is how many pages failed outright or yielded nothing but
+	// skipped products.
+	Pages int64
+	// Products is how many products were skipped.
+	Products int64
+	// Consecutive is the current run of drifted pages.
+	Consecutive int
+	// Degraded is set once Consecutive reaches the threshold and cleared
+	// by the next clean page.
+	Degraded bool
+}
+
+type driftTracker struct {
+	mu        sync.Mutex
+	threshold int
+	stats     DriftStats
+}
+
+// page records the outcome of a page and reports whether the degraded state
+// changed.
+func (d *driftTracker) page(drifted bool, skipped int) (changed bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.stats.Products += int64(skipped)
+	if !drifted {
+		d.stats.Consecutive = 0
+		changed = d.stats.Degraded
+		d.stats.Degraded = false
+		return changed
+	}
+
+	d.stats.Pages++
+	d.stats.Consecutive++
+	if d.threshold > 0 && d.stats.Consecutive >= d.threshold && !d.stats.Degraded {
+		d.stats.Degraded = true
+		return true
+	}
+	return false
+}
+
+func (d *driftTracker) snapshot() DriftStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.stats
+}
+
+// Drift returns the source's extraction failure counters.
+func (e *Engine) Drift() DriftStats {
+	return e.drift.snapshot()
+}
+
+// Degraded reports whether the source is degraded by schema drift. It
+// suits health.Degraded.
+func (e *Engine) Degraded() []string {
+	if e.drift.snapshot().Degraded {
+		return []string{e.source.Name()}
+	}
+	return nil
+}
+
+func (e *Engine) recordDrift(drifted bool, skipped int) {
+	if !e.drift.page(drifted, skipped) {
+		return
+	}
+	stats := e.drift.snapshot()
+	degraded := metrics.SourceDegraded.WithLabelValues(e.source.Name())
+	if stats.Degraded {
+		degraded.Set(1)
+		e.logger.Error("source degraded", "drifted_pages", stats.Consecutive)
+	} else {
+		degraded.Set(0)
+		e.logger.Info("source recovered")
+	}
+}
+
+func (e *Engine) quarantine(ctx context.Context, q model.Quarantined) {
+	if e.quarantiner == nil || e.cfg.DryRun {
+		return
+	}
+	q.Source = e.source.Name()
+	if err := e.quarantiner.Quarantine(ctx, q); err != nil {
+		e.logger.Error("quarantine", "keyword", q.Keyword, "page", q.Page, "error", err)
+	}
+}
@@ -0,0 +1,96 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/metrics"
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"github.com/mindsgn-studio/takealot-scraper/internal/store"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// quarantineStore keeps what the engine quarantines so tests can read it.
+type quarantineStore struct {
+	*store.Memory
+
+	mu  sync.Mutex
+	got []model.Quarantined
+}
+
+func (s *quarantineStore) Quarantine(_ context.Context, q model.Quarantined) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.got = append(s.got, q)
+	return nil
+}
+
+func TestScrapeKeywordQuarantinesSkipped(t *testing.T) {
+	st := &quarantineStore{Memory: store.NewMemory()}
+	page := Page{
+		Listings: []Listing{listing("1", 100)},
+		Skipped:  []Skipped{{ID: "2", Reason: "no_price", Sample: `{"id":2}`}},
+	}
+	src := &fakeSource{pages: map[string][]Page{"kettle": {page}}}
+	e := newTestEngine(t, model.Config{}, src, Options{DriftThreshold: 1}, st)
+
+	if err := e.ScrapeKeyword(context.Background(), "kettle"); err != nil {
+		t.Fatalf("ScrapeKeyword: %v", err)
+	}
+	want := []model.Quarantined{{Source: "fake", Keyword: "kettle", Page: 1, ProductID: "2", Reason: "no_price", Sample: `{"id":2}`}}
+	if !slices.Equal(st.got, want) {
+		t.Errorf("quarantined = %+v; want %+v", st.got, want)
+	}
+	// A page with listings is not drift, however many it skipped.
+	if drift := e.Drift(); drift != (DriftStats{Products: 1}) {
+		t.Errorf("drift = %+v", drift)
+	}
+	if got := items(t, st); len(got) != 1 {
+		t.Errorf("stored %d items; want 1", len(got))
+	}
+}
+
+func TestScrapeKeywordSchemaError(t *testing.T) {
+	st := &quarantineStore{Memory: store.NewMemory()}
+	schemaErr := &SchemaError{Reason: "zero product cards", Sample: "<html>"}
+	src := &fakeSource{errs: map[string]error{"kettle@": schemaErr, "toaster@": schemaErr}}
+	e := newTestEngine(t, model.Config{}, src, Options{DriftThreshold: 2}, st)
+	degraded := metrics.SourceDegraded.WithLabelValues("fake")
+
+	for i, keyword := range []string{"kettle", "toaster"} {
+		if err := e.ScrapeKeyword(context.Background(), keyword); !errors.Is(err, schemaErr) {
+			t.Fatalf("ScrapeKeyword(%s) = %v; want %v", keyword, err, schemaErr)
+		}
+		if got := len(st.got); got != i+1 {
+			t.Fatalf("quarantined %d pages; want %d", got, i+1)
+		}
+	}
+	if q := st.got[0]; q.Keyword != "kettle" || q.Page != 1 || q.Reason != schemaErr.Reason || q.Sample != schemaErr.Sample {
+		t.Errorf("quarantined = %+v", q)
+	}
+
+	// Two drifted pages in a row reach the threshold.
+	if drift := e.Drift(); !drift.Degraded || drift.Consecutive != 2 {
+		t.Errorf("drift = %+v; want degraded after 2 pages", drift)
+	}
+	if got := e.Degraded(); !slices.Equal(got, []string{"fake"}) {
+		t.Errorf("Degraded() = %v; want [fake]", got)
+	}
+	if got := testutil.ToFloat64(degraded); got != 1 {
+		t.Errorf("degraded gauge = %v; want 1", got)
+	}
+
+	// A clean page recovers the source.
+	if err := e.ScrapeKeyword(context.Background(), "blender"); err != nil {
+		t.Fatalf("ScrapeKeyword: %v", err)
+	}
+	if got := e.Degraded(); len(got) != 0 {
+		t.Errorf("Degraded() = %v after a clean page", got)
+	}
+	if got := testutil.ToFloat64(degraded); got != 0 {
+		t.Errorf("degraded gauge = %v; want 0", got)
+	}
+}
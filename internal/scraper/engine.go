@@ -0,0 +1,508 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/archive"
+	"github.com/mindsgn-studio/takealot-scraper/internal/health"
+	"github.com/mindsgn-studio/takealot-scraper/internal/metrics"
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"github.com/mindsgn-studio/takealot-scraper/internal/priority"
+	"github.com/mindsgn-studio/takealot-scraper/internal/recrawl"
+	"github.com/mindsgn-studio/takealot-scraper/internal/store"
+)
+
+const (
+	DefaultLeaseTTL      = 2 * time.Minute
+	DefaultLeaseCooldown = 6 * time.Hour
+	DefaultWorkers       = 4
+)
+
+// Options tune the Engine for a particular source.
+type Options struct {
+	// PriceDedupWindow is how long an unchanged price keeps extending its
+	// existing point before a fresh point is written anyway.
+	PriceDedupWindow time.Duration
+
+	// Workers is how many keywords are scraped in parallel. Request rates
+	// are limited per host by the source's transport, not here.
+	Workers int
+
+	// WorkerID identifies this process in keyword leases.
+	WorkerID string
+	// LeaseTTL is how long a keyword claim lasts without a heartbeat. Zero
+	// disables leasing, as do a dry run and a store that does not
+	// implement store.Leaser.
+	LeaseTTL time.Duration
+	// LeaseCooldown is how long a finished keyword is left alone before any
+	// worker may claim it again.
+	LeaseCooldown time.Duration
+
+	// DriftThreshold is how many drifted pages in a row mark the source
+	// as degraded. Zero never marks it.
+	DriftThreshold int
+
+	// Archive, when set, keeps every fetched page. Pages captured more
+	// than ArchiveRetention ago are pruned at the start of each pass.
+	Archive          archive.Archiver
+	ArchiveRetention time.Duration
+
+	// Host is the retailer host recorded in the run ledger.
+	Host string
+
+	// Priority weighs the signals that order each pass's keywords; the
+	// zero value keeps the shuffled order. KeywordsPerPass, when positive,
+	// crawls only the top of that order, leaving the rest to gain
+	// staleness until they rise.
+	Priority        priority.Weights
+	KeywordsPerPass int
+
+	// Recrawl learns how often each keyword is worth crawling; keywords
+	// not yet due are left out of a pass. The zero Policy crawls every
+	// keyword every pass, as does a store that does not implement
+	// store.Pacer.
+	Recrawl recrawl.Policy
+}
+
+type Engine struct {
+	cfg    model.Config
+	opts   Options
+	source Source
+	store  store.Store
+	leaser store.Leaser
+	// logger carries the current run's run_id while Run is going; base is
+	// the logger the engine was made with.
+	logger *slog.Logger
+	base   *slog.Logger
+
+	checkpoints store.Checkpointer
+	runID       string
+
+	quarantiner store.Quarantiner
+	drift       driftTracker
+
+	progress health.Progress
+
+	ledger store.Ledger
+	tally  runTally
+
+	pacer    store.Pacer
+	recrawls map[string]model.Recrawl
+
+	catalogue store.Catalogue
+}
+
+func NewEngine(cfg model.Config, source Source, opts Options, st store.Store, logger *slog.Logger) *Engine {
+	e := &Engine{
+		cfg:    cfg,
+		opts:   opts,
+		source: source,
+		store:  st,
+		logger: logger,
+		base:   logger,
+	}
+	// A dry run takes no leases: releasing one as finished would keep
+	// real workers off the keyword for the cooldown.
+	if leaser, ok := st.(store.Leaser); ok && opts.LeaseTTL > 0 && !cfg.DryRun {
+		e.leaser = leaser
+	}
+	if checkpoints, ok := st.(store.Checkpointer); ok {
+		e.checkpoints = checkpoints
+	}
+	if quarantiner, ok := st.(store.Quarantiner); ok {
+		e.quarantiner = quarantiner
+	}
+	if ledger, ok := st.(store.Ledger); ok {
+		e.ledger = ledger
+	}
+	if pacer, ok := st.(store.Pacer); ok {
+		e.pacer = pacer
+	}
+	if catalogue, ok := st.(store.Catalogue); ok {
+		e.catalogue = catalogue
+	}
+	e.drift.threshold = opts.DriftThreshold
+	metrics.SourceDegraded.WithLabelValues(source.Name()).Set(0)
+	e.progress.Touch()
+	return e
+}
+
+// LastProgress is when the engine last got a page back from its source,
+// successful or not.
+func (e *Engine) LastProgress() time.Time {
+	return e.progress.Last()
+}
+
+// LoadBrands adds the keywords in the brand file to brands and shuffles
+// them. It is how keywords are loaded when the store has no catalogue.
+func (e *Engine) LoadBrands(brands []string) ([]string, error) {
+	seed, err := e.readSeed()
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range seed {
+		if k.AppliesTo(e.source.Name()) {
+			brands = append(brands, k.Keyword)
+		}
+	}
+
+	brands = uniqueStrings(brands)
+	if len(brands) == 0 {
+		return nil, errors.New("no brands found")
+	}
+
+	rand.Shuffle(len(brands), func(i, j int) { brands[i], brands[j] = brands[j], brands[i] })
+
+	return brands, nil
+}
+
+func (e *Engine) Items(ctx context.Context) ([]string, error) {
+	brands, err := e.store.Brands(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	uniqueBrands := uniqueStrings(brands)
+
+	e.logger.Info("loaded brands from store", "count", len(uniqueBrands))
+	return uniqueBrands, nil
+}
+
+func (e *Engine) Run(ctx context.Context) (err error) {
+	defer func() { e.logger = e.base }()
+	e.startRun(ctx)
+	defer func() { e.finishRun(ctx, err) }()
+
+	brands, err := e.keywords(ctx)
+	if err != nil {
+		return err
+	}
+
+	brands = e.prioritise(ctx, e.dueKeywords(ctx, brands))
+	e.pruneArchive(ctx)
+	e.runID = e.startPass(ctx)
+	e.logger = e.base.With("run_id", e.runID)
+	e.logger.Info("starting pass", "keywords", len(brands))
+
+	pending := brands
+	for len(pending) > 0 {
+		held := e.runPending(ctx, pending)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// Keywords held by other workers are retried once their leases
+		// have had a chance to finish or expire.
+		if len(held) == 0 {
+			break
+		}
+		e.logger.Info("waiting on keywords leased by other workers", "count", len(held))
+		if err := sleep(ctx, e.opts.LeaseTTL); err != nil {
+			return err
+		}
+		pending = held
+	}
+	e.finishPass(ctx)
+	return nil
+}
+
+// runPending scrapes keywords on a pool of opts.Workers goroutines and
+// returns the keywords that were leased by other workers.
+func (e *Engine) runPending(ctx context.Context, keywords []string) []string {
+	jobs := make(chan string)
+	var (
+		mu   sync.Mutex
+		held []string
+		wg   sync.WaitGroup
+	)
+
+	workers := max(e.opts.Workers, 1)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for brand := range jobs {
+				log := e.logger.With("keyword", brand)
+				state, err := e.claim(ctx, brand)
+				if err != nil {
+					log.Error("claim keyword", "error", err)
+					e.tally.fail(ErrorLease)
+					continue
+				}
+				if state == store.LeaseHeld {
+					mu.Lock()
+					held = append(held, brand)
+					mu.Unlock()
+					continue
+				}
+				if state == store.LeaseDone {
+					continue
+				}
+
+				log.Info("scraping keyword")
+				e.tally.add(func(r *model.Run) { r.Keywords++ })
+				if err := e.scrapeLeased(ctx, brand); err != nil {
+					log.Error("scrape keyword", "error", err)
+					if ctx.Err() == nil {
+						e.tally.fail(errorCategory(err))
+					}
+				}
+				e.saveRun(ctx)
+			}
+		}()
+	}
+
+feed:
+	for _, brand := range keywords {
+		select {
+		case <-ctx.Done():
+			break feed
+		case jobs <- brand:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return held
+}
+
+func (e *Engine) ScrapeKeyword(ctx context.Context, keyword string) error {
+	log := e.logger.With("keyword", keyword)
+	cp := e.resume(ctx, keyword)
+	if cp.Done {
+		log.Info("skipping keyword already finished in this run")
+		return nil
+	}
+	cursor := cp.Cursor
+	page := cp.Page
+	changed := false
+	// A keyword without a learned interval is seeded from its items.
+	var items []string
+	seeding := e.needsSeed(keyword)
+	yield := model.KeywordStats{Crawls: 1}
+	searchCtx := ctx
+	if e.archiving() {
+		searchCtx = WithRaw(ctx)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		log.Debug("fetching page", "page", page, "cursor", cursor)
+		// Retries happen in the source's HTTP transport; whatever comes
+		// back here has already been retried or is not worth retrying.
+		result, err := e.source.Search(searchCtx, keyword, cursor)
+		e.progress.Touch()
+		if err != nil {
+			var schemaErr *SchemaError
+			if errors.As(err, &schemaErr) {
+				metrics.PagesFetched.WithLabelValues(e.source.Name()).Inc()
+				e.tally.add(func(r *model.Run) { r.Pages++ })
+				e.archivePage(ctx, keyword, page, schemaErr.Raw)
+				e.recordDrift(true, 0)
+				e.quarantine(ctx, model.Quarantined{
+					Keyword: keyword,
+					Page:    page,
+					Cursor:  cursor,
+					Reason:  schemaErr.Reason,
+					Sample:  schemaErr.Sample,
+				})
+			}
+			return fmt.Errorf("search page %d: %w", page, err)
+		}
+
+		e.archivePage(ctx, keyword, page, result.Raw)
+		e.countPage(result)
+		yield.Pages++
+		yield.Listings += int64(len(result.Listings))
+
+		for _, listing := range result.Listings {
+			itemID, write, err := e.persistAt(ctx, listing, time.Now().UTC())
+			if err != nil {
+				log.Error("persist listing", "page", page, "listing_id", listing.ID, "error", err)
+				e.tally.fail(ErrorPersist)
+				continue
+			}
+			if seeding && itemID != "" && len(items) < seedItems {
+				items = append(items, itemID)
+			}
+			if write == store.PriceChanged || write == store.PriceFirst {
+				changed = true
+			}
+			if write == store.PriceFirst {
+				yield.NewItems++
+			}
+		}
+		if len(result.Skipped) > 0 {
+			e.logSkipped(log, page, result.Skipped)
+			for _, s := range result.Skipped {
+				e.quarantine(ctx, model.Quarantined{
+					Keyword:   keyword,
+					Page:      page,
+					Cursor:    cursor,
+					ProductID: s.ID,
+					Reason:    string(s.Reason),
+					Sample:    s.Sample,
+				})
+			}
+		}
+		e.recordDrift(len(result.Listings) == 0 && len(result.Skipped) > 0, len(result.Skipped))
+
+		if result.Next == "" {
+			e.saveCheckpoint(ctx, model.Checkpoint{Keyword: keyword, RunID: e.runID, Page: page, Done: true})
+			break
+		}
+		cursor = result.Next
+		page++
+		e.saveCheckpoint(ctx, model.Checkpoint{Keyword: keyword, RunID: e.runID, Cursor: cursor, Page: page})
+	}
+	log.Info("finished keyword", "pages", page)
+	e.reschedule(ctx, keyword, changed, items)
+	e.recordYield(ctx, keyword, yield)
+	return nil
+}
+
+func (e *Engine) countPage(result Page) {
+	source := e.source.Name()
+	metrics.PagesFetched.WithLabelValues(source).Inc()
+	e.tally.add(func(r *model.Run) { r.Pages++ })
+	metrics.LastSuccessPage.WithLabelValues(source).SetToCurrentTime()
+	metrics.ProductsParsed.WithLabelValues(source).Add(float64(len(result.Listings)))
+	for _, s := range result.Skipped {
+		metrics.ProductsSkipped.WithLabelValues(source, string(s.Reason)).Inc()
+	}
+}
+
+// logSkipped summarises the results a source dropped from a page, listing
+// each one at debug level.
+func (e *Engine) logSkipped(log *slog.Logger, page int, skipped []Skipped) {
+	counts := make(map[SkipReason]int)
+	for _, s := range skipped {
+		counts[s.Reason]++
+		log.Debug("skipped product", "page", page, "listing_id", s.ID, "reason", string(s.Reason))
+	}
+
+	reasons := make([]string, 0, len(counts))
+	for reason := range counts {
+		reasons = append(reasons, string(reason))
+	}
+	sort.Strings(reasons)
+	attrs := make([]any, 0, len(reasons))
+	for _, reason := range reasons {
+		attrs = append(attrs, slog.Int(reason, counts[SkipReason(reason)]))
+	}
+	log.Warn("skipped products", "page", page, "count", len(skipped), slog.Group("reasons", attrs...))
+}
+
+func (e *Engine) Persist(ctx context.Context, listing Listing) error {
+	_, _, err := e.persistAt(ctx, listing, time.Now().UTC())
+	return err
+}
+
+// persistAt saves listing as seen at the given time and reports the item's
+// ID and what happened to its price. An out of stock listing without a price only
+// updates the item's availability, so the last real price is not followed
+// by a zero.
+func (e *Engine) persistAt(ctx context.Context, listing Listing, at time.Time) (string, store.PriceWrite, error) {
+	if listing.ID == "" {
+		return "", store.PriceTouched, errors.New("listing has no id")
+	}
+	if e.cfg.DryRun {
+		e.logger.Info("dry-run: would save listing", "listing_id", listing.ID, "price", listing.Price, "list_price", listing.ListPrice, "availability", listing.Availability, "title", listing.Title)
+		return "", store.PriceTouched, nil
+	}
+
+	item := model.Item{
+		Source:       e.source.Name(),
+		SourceID:     listing.ID,
+		Title:        listing.Title,
+		Images:       listing.Images,
+		Link:         listing.Link,
+		Brand:        listing.Brand,
+		Availability: listing.Availability,
+		Created:      at,
+		Updated:      at,
+	}
+	created, err := e.store.UpsertItem(ctx, &item)
+	if err != nil {
+		return "", store.PriceTouched, fmt.Errorf("save item: %w", err)
+	}
+	metrics.ItemUpserted(item.Source, created)
+	e.tally.add(func(r *model.Run) {
+		if created {
+			r.ItemsNew++
+		} else {
+			r.ItemsUpdated++
+		}
+	})
+	e.logger.Debug("saved item", "item_id", item.ID, "listing_id", listing.ID)
+	if listing.Price <= 0 && listing.Availability == model.OutOfStock {
+		return item.ID, store.PriceTouched, nil
+	}
+
+	price := model.Price{
+		ItemID:       item.ID,
+		Date:         at,
+		Currency:     "zar",
+		Price:        listing.Price,
+		Availability: listing.Availability,
+		LeadTime:     listing.LeadTime,
+	}
+	price.SetListPrice(listing.ListPrice)
+	write, err := e.savePrice(ctx, price)
+	if err != nil {
+		return item.ID, write, fmt.Errorf("save price for item %s: %w", item.ID, err)
+	}
+	return item.ID, write, nil
+}
+
+func (e *Engine) SavePriceIfStale(ctx context.Context, itemID string, priceVal float64) error {
+	_, err := e.savePrice(ctx, model.Price{
+		ItemID:   itemID,
+		Date:     time.Now().UTC(),
+		Currency: "zar",
+		Price:    priceVal,
+	})
+	return err
+}
+
+func (e *Engine) savePrice(ctx context.Context, price model.Price) (store.PriceWrite, error) {
+	write, err := store.RecordPrice(ctx, e.store, price, e.opts.PriceDedupWindow)
+	if err == nil && write.Inserted() {
+		metrics.PriceInserts.WithLabelValues(e.source.Name()).Inc()
+		e.tally.add(func(r *model.Run) { r.Prices++ })
+	}
+	return write, err
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func uniqueStrings(input []string) []string {
+	seen := make(map[string]struct{}, len(input))
+	out := make([]string, 0, len(input))
+	for _, v := range input {
+		if _, exists := seen[v]; !exists {
+			seen[v] = struct{}{}
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,338 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"github.com/mindsgn-studio/takealot-scraper/internal/store"
+)
+
+// fakeSource serves pages from memory. A keyword's first page is at cursor
+// "", and page n after it at cursor strconv.Itoa(n+1). Keywords without
+// pages get one empty page.
+type fakeSource struct {
+	pages map[string][]Page
+	// errs fails the search for "keyword@cursor".
+	errs map[string]error
+	// search, when set, runs before every search; an error it returns
+	// fails the search.
+	search func(ctx context.Context, keyword, cursor string) error
+
+	mu    sync.Mutex
+	calls []string
+}
+
+func (s *fakeSource) Name() string { return "fake" }
+
+func (s *fakeSource) Search(ctx context.Context, keyword, cursor string) (Page, error) {
+	call := keyword + "@" + cursor
+	s.mu.Lock()
+	s.calls = append(s.calls, call)
+	s.mu.Unlock()
+
+	if s.search != nil {
+		if err := s.search(ctx, keyword, cursor); err != nil {
+			return Page{}, err
+		}
+	}
+	if err := s.errs[call]; err != nil {
+		return Page{}, err
+	}
+	pages := s.pages[keyword]
+	i := 0
+	if cursor != "" {
+		n, err := strconv.Atoi(cursor)
+		if err != nil {
+			return Page{}, err
+		}
+		i = n - 1
+	}
+	if i >= len(pages) {
+		return Page{}, nil
+	}
+	return pages[i], nil
+}
+
+// Calls returns the searches made so far as "keyword@cursor", sorted.
+func (s *fakeSource) Calls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	calls := slices.Clone(s.calls)
+	slices.Sort(calls)
+	return calls
+}
+
+// pages chains listings into pages, one slice per page.
+func pages(listings ...[]Listing) []Page {
+	out := make([]Page, len(listings))
+	for i, l := range listings {
+		out[i].Listings = l
+		if i < len(listings)-1 {
+			out[i].Next = strconv.Itoa(i + 2)
+		}
+	}
+	return out
+}
+
+func listing(id string, price float64) Listing {
+	return Listing{
+		ID:           id,
+		Title:        "Item " + id,
+		Link:         "https://example.com/" + id,
+		Price:        price,
+		Availability: model.InStock,
+	}
+}
+
+// newTestEngine returns an engine over src and st whose brand file seeds
+// the given keywords.
+func newTestEngine(t *testing.T, cfg model.Config, src Source, opts Options, st store.Store, keywords ...string) *Engine {
+	t.Helper()
+	cfg.BrandFile = filepath.Join(t.TempDir(), "brands.txt")
+	if err := os.WriteFile(cfg.BrandFile, []byte(strings.Join(keywords, ",")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return NewEngine(cfg, src, opts, st, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// items returns the stored items keyed by source ID.
+func items(t *testing.T, st store.Store) map[string]model.Item {
+	t.Helper()
+	out := make(map[string]model.Item)
+	err := st.EachItem(context.Background(), func(item model.Item) error {
+		out[item.SourceID] = item
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func history(t *testing.T, st store.Store, itemID string) []model.Price {
+	t.Helper()
+	prices, err := st.PriceHistory(context.Background(), itemID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return prices
+}
+
+func TestScrapeKeyword(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemory()
+	src := &fakeSource{pages: map[string][]Page{
+		"kettle": pages(
+			[]Listing{listing("1", 100), listing("2", 200)},
+			[]Listing{listing("3", 300)},
+		),
+	}}
+	e := newTestEngine(t, model.Config{}, src, Options{PriceDedupWindow: time.Hour}, st)
+
+	if err := e.ScrapeKeyword(ctx, "kettle"); err != nil {
+		t.Fatalf("ScrapeKeyword: %v", err)
+	}
+	if got, want := src.Calls(), []string{"kettle@", "kettle@2"}; !slices.Equal(got, want) {
+		t.Errorf("searches = %v; want %v", got, want)
+	}
+	got := items(t, st)
+	if len(got) != 3 {
+		t.Fatalf("stored %d items; want 3", len(got))
+	}
+	for id, want := range map[string]float64{"1": 100, "2": 200, "3": 300} {
+		item := got[id]
+		if item.Source != "fake" || item.Title != "Item "+id {
+			t.Errorf("item %s = %+v", id, item)
+		}
+		prices := history(t, st, item.ID)
+		if len(prices) != 1 || prices[0].Price != want {
+			t.Errorf("item %s prices = %+v; want one at %v", id, prices, want)
+		}
+	}
+
+	// A second crawl at the same prices only extends the existing points;
+	// a changed price adds one.
+	src.pages["kettle"][1].Listings[0].Price = 250
+	e.runID = "next"
+	if err := e.ScrapeKeyword(ctx, "kettle"); err != nil {
+		t.Fatalf("second ScrapeKeyword: %v", err)
+	}
+	for id, want := range map[string]int{"1": 1, "2": 1, "3": 2} {
+		if prices := history(t, st, got[id].ID); len(prices) != want {
+			t.Errorf("item %s has %d prices; want %d", id, len(prices), want)
+		}
+	}
+}
+
+func TestScrapeKeywordSearchError(t *testing.T) {
+	st := store.NewMemory()
+	fail := errors.New("connection reset")
+	src := &fakeSource{
+		pages: map[string][]Page{"kettle": pages([]Listing{listing("1", 100)}, []Listing{listing("2", 200)})},
+		errs:  map[string]error{"kettle@2": fail},
+	}
+	e := newTestEngine(t, model.Config{}, src, Options{}, st)
+
+	if err := e.ScrapeKeyword(context.Background(), "kettle"); !errors.Is(err, fail) {
+		t.Fatalf("ScrapeKeyword = %v; want %v", err, fail)
+	}
+	// Pages before the failure are kept.
+	if got := items(t, st); len(got) != 1 {
+		t.Errorf("stored %d items; want 1", len(got))
+	}
+}
+
+func TestPersist(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemory()
+	e := newTestEngine(t, model.Config{}, &fakeSource{}, Options{}, st)
+
+	if err := e.Persist(ctx, Listing{Title: "No id", Price: 10}); err == nil {
+		t.Error("Persist without an ID succeeded")
+	}
+
+	if err := e.Persist(ctx, listing("1", 100)); err != nil {
+		t.Fatal(err)
+	}
+	gone := listing("1", 0)
+	gone.Availability = model.OutOfStock
+	if err := e.Persist(ctx, gone); err != nil {
+		t.Fatal(err)
+	}
+
+	item := items(t, st)["1"]
+	if item.Availability != model.OutOfStock {
+		t.Errorf("availability = %v; want %v", item.Availability, model.OutOfStock)
+	}
+	// The out of stock sighting has no price, so the last real one stands.
+	prices := history(t, st, item.ID)
+	if len(prices) != 1 || prices[0].Price != 100 {
+		t.Errorf("prices = %+v; want only the 100 point", prices)
+	}
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemory()
+	src := &fakeSource{pages: map[string][]Page{
+		"kettle":  pages([]Listing{listing("1", 100)}, []Listing{listing("2", 200)}),
+		"toaster": pages([]Listing{listing("3", 300)}),
+	}}
+	e := newTestEngine(t, model.Config{}, src, Options{}, st, "kettle", "toaster")
+
+	if err := e.Run(ctx); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got, want := src.Calls(), []string{"kettle@", "kettle@2", "toaster@"}; !slices.Equal(got, want) {
+		t.Errorf("searches = %v; want %v", got, want)
+	}
+	if got := items(t, st); len(got) != 3 {
+		t.Errorf("stored %d items; want 3", len(got))
+	}
+
+	runs, err := st.ListRuns(ctx, "fake", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 {
+		t.Fatalf("ledger has %d runs; want 1", len(runs))
+	}
+	run := runs[0]
+	if run.Keywords != 2 || run.Pages != 3 || run.ItemsNew != 3 || run.Prices != 3 || run.Exit != model.RunCompleted {
+		t.Errorf("run = %+v", run)
+	}
+}
+
+func TestRunDryRun(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemory()
+	src := &fakeSource{pages: map[string][]Page{"kettle": pages([]Listing{listing("1", 100)})}}
+	e := newTestEngine(t, model.Config{DryRun: true}, src, Options{}, st, "kettle")
+
+	if err := e.Run(ctx); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := src.Calls(); len(got) != 1 {
+		t.Errorf("searches = %v; want one", got)
+	}
+	if got := items(t, st); len(got) != 0 {
+		t.Errorf("dry run stored %d items", len(got))
+	}
+	if runs, _ := st.ListRuns(ctx, "", 0); len(runs) != 0 {
+		t.Errorf("dry run recorded %d runs", len(runs))
+	}
+	if cps, _ := st.ListCheckpoints(ctx, "fake"); len(cps) != 0 {
+		t.Errorf("dry run saved checkpoints %+v", cps)
+	}
+	if kws, _ := st.ListKeywords(ctx); len(kws) != 0 {
+		t.Errorf("dry run seeded the catalogue with %+v", kws)
+	}
+}
+
+func TestRunWorkers(t *testing.T) {
+	const workers = 3
+	var (
+		mu       sync.Mutex
+		inFlight int
+		peak     int
+		full     = make(chan struct{})
+	)
+	src := &fakeSource{search: func(ctx context.Context, _, _ string) error {
+		mu.Lock()
+		inFlight++
+		peak = max(peak, inFlight)
+		if inFlight == workers {
+			select {
+			case <-full:
+			default:
+				close(full)
+			}
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+
+		// Hold the first searches until every worker is busy.
+		select {
+		case <-full:
+			return nil
+		case <-time.After(5 * time.Second):
+			return errors.New("workers never all busy")
+		}
+	}}
+	keywords := []string{"a", "b", "c", "d", "e", "f", "g"}
+	st := store.NewMemory()
+	e := newTestEngine(t, model.Config{}, src, Options{Workers: workers}, st, keywords...)
+
+	if err := e.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if peak != workers {
+		t.Errorf("peak concurrency = %d; want %d", peak, workers)
+	}
+	var want []string
+	for _, k := range keywords {
+		want = append(want, k+"@")
+	}
+	if got := src.Calls(); !slices.Equal(got, want) {
+		t.Errorf("searches = %v; want each keyword once: %v", got, want)
+	}
+	runs, _ := st.ListRuns(context.Background(), "fake", 0)
+	if len(runs) != 1 || runs[0].Keywords != int64(len(keywords)) || len(runs[0].Errors) != 0 {
+		t.Errorf("runs = %+v", runs)
+	}
+}
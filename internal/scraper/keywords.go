@@ -0,0 +1,120 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/catalogue"
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"github.com/mindsgn-studio/takealot-scraper/internal/store"
+)
+
+const (
+	// CategoryBrand is the category of keywords taken from item brands.
+	CategoryBrand = "brand"
+
+	AddedBySeed   = "seed"
+	AddedByBrands = "brands"
+)
+
+// keywords returns this pass's keywords, shuffled. With a catalogue they
+// are its enabled keywords for the source, after seeding an empty catalogue
+// from the brand file and adding any item brands it lacks; without one
+// they are the brand file's keywords and item brands.
+func (e *Engine) keywords(ctx context.Context) ([]string, error) {
+	brands, err := e.Items(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load items: %w", err)
+	}
+	if e.catalogue == nil {
+		return e.LoadBrands(brands)
+	}
+
+	listed, err := e.catalogue.ListKeywords(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list keywords: %w", err)
+	}
+	known := make(map[string]struct{}, len(listed))
+	for _, k := range listed {
+		known[strings.ToLower(k.Keyword)] = struct{}{}
+	}
+
+	var missing []model.Keyword
+	if len(listed) == 0 {
+		seed, err := e.readSeed()
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range seed {
+			k.AddedBy = AddedBySeed
+			missing = append(missing, k)
+			known[strings.ToLower(k.Keyword)] = struct{}{}
+		}
+	}
+	for _, brand := range brands {
+		brand = strings.TrimSpace(brand)
+		key := strings.ToLower(brand)
+		if _, ok := known[key]; ok || brand == "" {
+			continue
+		}
+		known[key] = struct{}{}
+		missing = append(missing, model.Keyword{Keyword: brand, Category: CategoryBrand, Enabled: true, AddedBy: AddedByBrands})
+	}
+	if len(missing) > 0 {
+		if e.cfg.DryRun {
+			e.logger.Info("dry-run: would add keywords to catalogue", "count", len(missing))
+		} else {
+			added, err := e.catalogue.AddKeywords(ctx, missing...)
+			if err != nil {
+				return nil, fmt.Errorf("add keywords: %w", err)
+			}
+			e.logger.Info("added keywords to catalogue", "count", added)
+		}
+		listed = append(listed, missing...)
+	}
+
+	source := e.source.Name()
+	keywords := make([]string, 0, len(listed))
+	for _, k := range listed {
+		if k.Enabled && k.AppliesTo(source) {
+			keywords = append(keywords, k.Keyword)
+		}
+	}
+	if len(keywords) == 0 {
+		return nil, fmt.Errorf("no enabled keywords for %s", source)
+	}
+	rand.Shuffle(len(keywords), func(i, j int) { keywords[i], keywords[j] = keywords[j], keywords[i] })
+
+	e.logger.Info("loaded keywords from catalogue", "count", len(keywords), "catalogue", len(listed))
+	return keywords, nil
+}
+
+func (e *Engine) readSeed() ([]model.Keyword, error) {
+	f, err := os.Open(e.cfg.BrandFile)
+	if err != nil {
+		return nil, fmt.Errorf("read brand file: %w", err)
+	}
+	defer f.Close()
+	return catalogue.ParseSeed(f, "")
+}
+
+// recordYield adds a finished crawl of keyword to its catalogue stats.
+func (e *Engine) recordYield(ctx context.Context, keyword string, yield model.KeywordStats) {
+	if e.catalogue == nil || e.cfg.DryRun {
+		return
+	}
+	yield.LastCrawled = time.Now().UTC()
+	err := e.catalogue.RecordKeywordCrawl(ctx, keyword, e.source.Name(), yield)
+	if errors.Is(err, store.ErrNotFound) {
+		e.logger.Debug("keyword not in catalogue; yield not recorded", "keyword", keyword)
+		return
+	}
+	if err != nil {
+		e.logger.Error("record keyword yield", "keyword", keyword, "error", err)
+	}
+}
@@ -0,0 +1,75 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/store"
+)
+
+// DefaultWorkerID names this process in leases when no ID is configured.
+func DefaultWorkerID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+func (e *Engine) claim(ctx context.Context, keyword string) (store.LeaseState, error) {
+	if e.leaser == nil {
+		return store.LeaseClaimed, nil
+	}
+	return e.leaser.ClaimLease(ctx, e.source.Name(), keyword, e.opts.WorkerID, e.opts.LeaseTTL, e.opts.LeaseCooldown)
+}
+
+// scrapeLeased scrapes keyword while renewing its lease in the background.
+// If the lease is lost to another worker the scrape is abandoned.
+func (e *Engine) scrapeLeased(ctx context.Context, keyword string) error {
+	if e.leaser == nil {
+		return e.ScrapeKeyword(ctx, keyword)
+	}
+
+	scrapeCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(e.opts.LeaseTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-scrapeCtx.Done():
+				return
+			case <-ticker.C:
+				err := e.leaser.RenewLease(scrapeCtx, e.source.Name(), keyword, e.opts.WorkerID, e.opts.LeaseTTL)
+				if errors.Is(err, store.ErrLeaseLost) {
+					cancel(err)
+					return
+				}
+				if err != nil {
+					e.logger.Error("renew lease", "keyword", keyword, "error", err)
+				}
+			}
+		}
+	}()
+
+	err := e.ScrapeKeyword(scrapeCtx, keyword)
+	cancel(nil)
+	<-done
+	if cause := context.Cause(scrapeCtx); errors.Is(cause, store.ErrLeaseLost) {
+		return fmt.Errorf("brand %s: %w", keyword, cause)
+	}
+
+	// Release even when shutting down so another worker can pick the
+	// keyword up straight away.
+	releaseCtx := context.WithoutCancel(ctx)
+	if rerr := e.leaser.ReleaseLease(releaseCtx, e.source.Name(), keyword, e.opts.WorkerID, err == nil); rerr != nil {
+		e.logger.Error("release lease", "keyword", keyword, "error", rerr)
+	}
+	return err
+}
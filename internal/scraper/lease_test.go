@@ -0,0 +1,112 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"github.com/mindsgn-studio/takealot-scraper/internal/store"
+)
+
+// lostLeases is a store whose leases are always lost on renewal.
+type lostLeases struct {
+	*store.Memory
+}
+
+func (lostLeases) RenewLease(context.Context, string, string, string, time.Duration) error {
+	return store.ErrLeaseLost
+}
+
+func leaseOptions(ttl time.Duration) Options {
+	return Options{WorkerID: "me", LeaseTTL: ttl, LeaseCooldown: time.Hour}
+}
+
+// claimAs claims keyword for owner and fails the test unless the claim
+// ends in want.
+func claimAs(t *testing.T, st store.Leaser, owner, keyword string, ttl time.Duration, want store.LeaseState) {
+	t.Helper()
+	got, err := st.ClaimLease(context.Background(), "fake", keyword, owner, ttl, time.Hour)
+	if err != nil || got != want {
+		t.Fatalf("ClaimLease(%s, %s) = %v, %v; want %v", owner, keyword, got, err, want)
+	}
+}
+
+func TestRunReleasesFinishedKeywords(t *testing.T) {
+	st := store.NewMemory()
+	fail := errors.New("connection reset")
+	src := &fakeSource{errs: map[string]error{"toaster@": fail}}
+	e := newTestEngine(t, model.Config{}, src, leaseOptions(time.Minute), st, "kettle", "toaster")
+
+	if err := e.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	// A finished keyword cools down; a failed one is free for the next
+	// worker straight away.
+	claimAs(t, st, "other", "kettle", time.Minute, store.LeaseDone)
+	claimAs(t, st, "other", "toaster", time.Minute, store.LeaseClaimed)
+}
+
+func TestRunSkipsFinishedKeywords(t *testing.T) {
+	st := store.NewMemory()
+	claimAs(t, st, "other", "kettle", time.Minute, store.LeaseClaimed)
+	if err := st.ReleaseLease(context.Background(), "fake", "kettle", "other", true); err != nil {
+		t.Fatal(err)
+	}
+	src := &fakeSource{}
+	e := newTestEngine(t, model.Config{}, src, leaseOptions(time.Minute), st, "kettle", "toaster")
+
+	if err := e.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got, want := src.Calls(), []string{"toaster@"}; !slices.Equal(got, want) {
+		t.Errorf("searches = %v; want %v", got, want)
+	}
+}
+
+func TestRunWaitsForHeldKeywords(t *testing.T) {
+	st := store.NewMemory()
+	ttl := 50 * time.Millisecond
+	// Another worker holds kettle but stops heartbeating, so its lease
+	// expires while this worker waits.
+	claimAs(t, st, "other", "kettle", ttl, store.LeaseClaimed)
+	src := &fakeSource{}
+	e := newTestEngine(t, model.Config{}, src, leaseOptions(ttl), st, "kettle", "toaster")
+
+	if err := e.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got, want := src.Calls(), []string{"kettle@", "toaster@"}; !slices.Equal(got, want) {
+		t.Errorf("searches = %v; want %v", got, want)
+	}
+}
+
+func TestScrapeLeasedLostLease(t *testing.T) {
+	st := lostLeases{store.NewMemory()}
+	src := &fakeSource{search: func(ctx context.Context, _, _ string) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+	e := newTestEngine(t, model.Config{}, src, leaseOptions(30*time.Millisecond), st)
+
+	claimAs(t, st, "me", "kettle", time.Minute, store.LeaseClaimed)
+	if err := e.scrapeLeased(context.Background(), "kettle"); !errors.Is(err, store.ErrLeaseLost) {
+		t.Fatalf("scrapeLeased = %v; want %v", err, store.ErrLeaseLost)
+	}
+	// The lease belongs to whoever took it, so it is not released.
+	claimAs(t, st, "other", "kettle", time.Minute, store.LeaseHeld)
+}
+
+func TestRunDryRunTakesNoLeases(t *testing.T) {
+	st := store.NewMemory()
+	e := newTestEngine(t, model.Config{DryRun: true}, &fakeSource{}, leaseOptions(time.Minute), st, "kettle")
+
+	if err := e.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	// Releasing the lease as finished would keep real workers off kettle
+	// for the cooldown.
+	claimAs(t, st, "other", "kettle", time.Minute, store.LeaseClaimed)
+}
@@ -0,0 +1,125 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"github.com/mindsgn-studio/takealot-scraper/internal/store"
+)
+
+// Error categories counted in the run ledger.
+const (
+	ErrorFetch      = "fetch"
+	ErrorSchema     = "schema"
+	ErrorPersist    = "persist"
+	ErrorLease      = "lease"
+	ErrorCheckpoint = "checkpoint"
+)
+
+// runTally accumulates the counts for the current run's ledger entry.
+type runTally struct {
+	mu  sync.Mutex
+	run model.Run
+}
+
+func (t *runTally) add(fn func(*model.Run)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fn(&t.run)
+}
+
+func (t *runTally) fail(category string) {
+	t.add(func(r *model.Run) {
+		if r.Errors == nil {
+			r.Errors = make(map[string]int64)
+		}
+		r.Errors[category]++
+	})
+}
+
+func (t *runTally) snapshot() model.Run {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	run := t.run
+	run.Errors = maps.Clone(t.run.Errors)
+	return run
+}
+
+// errorCategory sorts a failed keyword's error for the ledger.
+func errorCategory(err error) string {
+	var schemaErr *SchemaError
+	switch {
+	case errors.As(err, &schemaErr):
+		return ErrorSchema
+	case errors.Is(err, store.ErrLeaseLost):
+		return ErrorLease
+	default:
+		return ErrorFetch
+	}
+}
+
+// Summary returns the counts of the current or last run so far.
+func (e *Engine) Summary() model.Run {
+	return e.tally.snapshot()
+}
+
+func (e *Engine) startRun(ctx context.Context) {
+	e.tally.add(func(r *model.Run) {
+		*r = model.Run{
+			ID:       newRunID(),
+			Source:   e.source.Name(),
+			Host:     e.opts.Host,
+			WorkerID: e.opts.WorkerID,
+			Started:  time.Now().UTC(),
+			Exit:     model.RunRunning,
+		}
+	})
+	e.saveRun(ctx)
+}
+
+// finishRun records how the run ended. It still writes when ctx has been
+// cancelled, since cancellation is one of the outcomes worth recording.
+func (e *Engine) finishRun(ctx context.Context, err error) {
+	e.tally.add(func(r *model.Run) {
+		r.Pass = e.runID
+		r.Finished = time.Now().UTC()
+		switch {
+		case err == nil:
+			r.Exit = model.RunCompleted
+		case errors.Is(err, context.Canceled):
+			r.Exit = model.RunCancelled
+		default:
+			r.Exit = model.RunFailed
+			r.Error = err.Error()
+		}
+	})
+	e.saveRun(context.WithoutCancel(ctx))
+
+	run := e.tally.snapshot()
+	e.logger.Info("run finished",
+		"ledger_id", run.ID,
+		"exit", run.Exit,
+		"duration", run.Finished.Sub(run.Started).Round(time.Second),
+		"keywords", run.Keywords,
+		"pages", run.Pages,
+		"items_new", run.ItemsNew,
+		"items_updated", run.ItemsUpdated,
+		"prices", run.Prices,
+		"errors", run.Errors,
+	)
+}
+
+func (e *Engine) saveRun(ctx context.Context) {
+	if e.ledger == nil || e.cfg.DryRun {
+		return
+	}
+	run := e.tally.snapshot()
+	run.Pass = e.runID
+	if err := e.ledger.SaveRun(ctx, run); err != nil {
+		e.logger.Error("save run", "error", err)
+	}
+}
@@ -0,0 +1,70 @@
+package scraper
+
+import (
+	"context"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/priority"
+)
+
+// prioritise orders keywords by their priority score, highest first, and
+// trims them to opts.KeywordsPerPass. Without weights the order is left as
+// it is. A keyword's last crawl is the later of its checkpoint and the last
+// time any of its items was seen.
+func (e *Engine) prioritise(ctx context.Context, keywords []string) []string {
+	if e.opts.Priority != (priority.Weights{}) {
+		keywords = e.rank(ctx, keywords)
+	}
+	if n := e.opts.KeywordsPerPass; n > 0 && n < len(keywords) {
+		e.logger.Info("limiting pass to top keywords", "keywords", n, "deferred", len(keywords)-n)
+		keywords = keywords[:n]
+	}
+	return keywords
+}
+
+func (e *Engine) rank(ctx context.Context, keywords []string) []string {
+	now := time.Now().UTC()
+	table, err := priority.Collect(ctx, e.store, now, priority.DefaultWindow)
+	if err != nil {
+		e.logger.Error("collect priority signals; keeping random order", "error", err)
+		return keywords
+	}
+	crawled := e.lastCrawled(ctx)
+
+	source := e.source.Name()
+	ranked := priority.Rank(keywords, func(keyword string) priority.Signals {
+		s, _ := table.Brand(source, keyword)
+		if t := crawled[keyword]; t.After(s.LastSeen) {
+			s.LastSeen = t
+		}
+		return s
+	}, now, e.opts.Priority)
+
+	out := make([]string, len(ranked))
+	for i, r := range ranked {
+		out[i] = r.Key
+		if i < 5 {
+			e.logger.Debug("keyword priority", "rank", i+1, "keyword", r.Key, "score", r.Score)
+		}
+	}
+	return out
+}
+
+// lastCrawled maps keywords to when their checkpoint was last written.
+func (e *Engine) lastCrawled(ctx context.Context) map[string]time.Time {
+	crawled := make(map[string]time.Time)
+	if e.checkpoints == nil {
+		return crawled
+	}
+	checkpoints, err := e.checkpoints.ListCheckpoints(ctx, e.source.Name())
+	if err != nil {
+		e.logger.Error("list checkpoints", "error", err)
+		return crawled
+	}
+	for _, cp := range checkpoints {
+		if cp.Keyword != passKeyword {
+			crawled[cp.Keyword] = cp.Updated
+		}
+	}
+	return crawled
+}
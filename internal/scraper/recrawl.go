@@ -0,0 +1,87 @@
+package scraper
+
+import (
+	"context"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"github.com/mindsgn-studio/takealot-scraper/internal/recrawl"
+)
+
+// dueKeywords drops the keywords whose learned recrawl interval has not yet
+// passed and remembers the records of the rest for rescheduling.
+func (e *Engine) dueKeywords(ctx context.Context, keywords []string) []string {
+	if e.pacer == nil || !e.opts.Recrawl.Enabled() {
+		return keywords
+	}
+	recrawls, err := e.pacer.Recrawls(ctx, model.RecrawlKeyword, e.source.Name())
+	if err != nil {
+		e.logger.Error("load recrawl intervals; crawling every keyword", "error", err)
+		return keywords
+	}
+	e.recrawls = recrawls
+
+	now := time.Now().UTC()
+	due := make([]string, 0, len(keywords))
+	for _, keyword := range keywords {
+		r, ok := recrawls[keyword]
+		if recrawl.Due(r, ok, now) {
+			due = append(due, keyword)
+		}
+	}
+	if deferred := len(keywords) - len(due); deferred > 0 {
+		e.logger.Info("deferring keywords not yet due", "due", len(due), "deferred", deferred)
+	}
+	return due
+}
+
+// seedItems bounds how many of a new keyword's items have their price
+// history read to seed its interval.
+const seedItems = 50
+
+// needsSeed reports whether keyword has no learned interval yet, so its
+// crawl should note the items it returns.
+func (e *Engine) needsSeed(keyword string) bool {
+	if e.pacer == nil || !e.opts.Recrawl.Enabled() || e.cfg.DryRun {
+		return false
+	}
+	return e.recrawls[keyword].Interval <= 0
+}
+
+// reschedule sets keyword's next visit after a crawl that did or did not
+// see a new or changed price. A keyword without an interval yet is seeded
+// from the price histories of the items the crawl returned.
+func (e *Engine) reschedule(ctx context.Context, keyword string, changed bool, items []string) {
+	if e.pacer == nil || !e.opts.Recrawl.Enabled() || e.cfg.DryRun {
+		return
+	}
+	r, ok := e.recrawls[keyword]
+	if !ok {
+		r = model.Recrawl{Kind: model.RecrawlKeyword, Source: e.source.Name(), Key: keyword}
+	}
+	seed := e.opts.Recrawl.Initial
+	if r.Interval <= 0 {
+		seed = e.seedKeyword(ctx, keyword, items)
+	}
+	r = e.opts.Recrawl.Next(r, changed, seed, time.Now().UTC())
+	if err := e.pacer.SaveRecrawl(ctx, r); err != nil {
+		e.logger.Error("save recrawl interval", "keyword", keyword, "error", err)
+		return
+	}
+	e.logger.Debug("rescheduled keyword", "keyword", keyword, "changed", changed, "interval", r.Interval, "next_due_at", r.NextDue)
+}
+
+// seedKeyword estimates a first interval for keyword from the price
+// histories of its items.
+func (e *Engine) seedKeyword(ctx context.Context, keyword string, items []string) time.Duration {
+	histories := make([][]model.Price, 0, len(items))
+	for _, id := range items {
+		history, err := e.store.PriceHistory(ctx, id)
+		if err != nil {
+			e.logger.Error("price history", "keyword", keyword, "item_id", id, "error", err)
+			continue
+		}
+		histories = append(histories, history)
+	}
+	return e.opts.Recrawl.SeedKeyword(histories)
+}
@@ -0,0 +1,59 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/archive"
+)
+
+// ReprocessStats summarises a Reprocess run.
+type ReprocessStats struct {
+	Pages    int
+	Failed   int
+	Listings int
+	Skipped  int
+}
+
+// Reprocess runs the source's extraction again over archived pages matching
+// f and saves the results as if they had been seen when the page was
+// captured. Price points are written at the capture time; an item's fields
+// are only taken from a capture newer than the item's last update, so
+// backfilling old pages adds history without reverting live items.
+func (e *Engine) Reprocess(ctx context.Context, arc archive.Archiver, f archive.Filter) (ReprocessStats, error) {
+	var stats ReprocessStats
+	parser, ok := e.source.(Parser)
+	if !ok {
+		return stats, fmt.Errorf("source %s cannot parse archived pages", e.source.Name())
+	}
+	f.Source = e.source.Name()
+
+	err := arc.Each(ctx, f, func(entry archive.Entry, raw []byte) error {
+		stats.Pages++
+		e.progress.Touch()
+		log := e.logger.With("keyword", entry.Keyword, "page", entry.Page, "captured", entry.Captured)
+		page, err := parser.ParseRaw(ctx, raw)
+		if err != nil {
+			stats.Failed++
+			var schemaErr *SchemaError
+			if !errors.As(err, &schemaErr) {
+				return err
+			}
+			log.Warn("reprocess page", "error", err)
+			return nil
+		}
+
+		for _, listing := range page.Listings {
+			if _, _, err := e.persistAt(ctx, listing, entry.Captured); err != nil {
+				log.Error("persist listing", "listing_id", listing.ID, "error", err)
+				continue
+			}
+			stats.Listings++
+		}
+		stats.Skipped += len(page.Skipped)
+		log.Debug("reprocessed page", "listings", len(page.Listings), "skipped", len(page.Skipped))
+		return nil
+	})
+	return stats, err
+}
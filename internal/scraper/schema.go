@@ -0,0 +1,52 @@
+package scraper
+
+import "fmt"
+
+// MaxSampleBytes bounds the raw payload kept with a quarantined page or
+// product.
+const MaxSampleBytes = 4 << 10
+
+// SchemaError is returned by a Source when a response no longer has the
+// shape it expects, as opposed to a network or HTTP failure.
+type SchemaError struct {
+	Reason string
+	// Sample is the start of the raw response, trimmed to MaxSampleBytes.
+	Sample string
+	// Raw is the whole response when the context asked for it with
+	// WithRaw.
+	Raw []byte
+}
+
+func (e *SchemaError) Error() string {
+	return "schema drift: " + e.Reason
+}
+
+// Sample trims a raw payload for quarantine.
+func Sample(raw []byte) string {
+	if len(raw) <= MaxSampleBytes {
+		return string(raw)
+	}
+	return string(raw[:MaxSampleBytes]) + fmt.Sprintf("... (%d bytes total)", len(raw))
+}
+
+// SampleWriter keeps the first MaxSampleBytes written to it, so a streamed
+// body can be sampled with io.TeeReader without buffering all of it.
+type SampleWriter struct {
+	buf   []byte
+	total int
+}
+
+func (w *SampleWriter) Write(p []byte) (int, error) {
+	w.total += len(p)
+	if room := MaxSampleBytes - len(w.buf); room > 0 {
+		w.buf = append(w.buf, p[:min(room, len(p))]...)
+	}
+	return len(p), nil
+}
+
+func (w *SampleWriter) String() string {
+	if w.total <= len(w.buf) {
+		return string(w.buf)
+	}
+	return string(w.buf) + fmt.Sprintf("... (%d bytes read)", w.total)
+}
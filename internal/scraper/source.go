@@ -0,0 +1,72 @@
+package scraper
+
+import (
+	"context"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+)
+
+// Listing is a single product as seen on a retailer's search results.
+// ListPrice is the was price shown struck through next to Price, zero when
+// the product is not on sale. LeadTime is set only when Availability is
+// model.LeadTime.
+type Listing struct {
+	ID           string
+	Title        string
+	Brand        string
+	Link         string
+	Images       []string
+	Price        float64
+	ListPrice    float64
+	Availability model.Availability
+	LeadTime     string
+}
+
+// SkipReason says why a search result did not become a Listing.
+type SkipReason string
+
+// Skipped is a search result a source dropped. ID is the retailer's product
+// ID when it could be read; Sample is the raw result, trimmed with Sample.
+type Skipped struct {
+	ID     string
+	Reason SkipReason
+	Sample string
+}
+
+// Page is one page of search results. Next is the cursor for the following
+// page and is empty when there are no more pages. Raw is the response body,
+// set only when the context asked for it with WithRaw.
+type Page struct {
+	Listings []Listing
+	Skipped  []Skipped
+	Next     string
+	Raw      []byte
+}
+
+type wantRawKey struct{}
+
+// WithRaw asks a Source to return the raw response in Page.Raw, or in
+// SchemaError.Raw when parsing fails.
+func WithRaw(ctx context.Context) context.Context {
+	return context.WithValue(ctx, wantRawKey{}, true)
+}
+
+// WantRaw reports whether ctx was made by WithRaw.
+func WantRaw(ctx context.Context) bool {
+	want, _ := ctx.Value(wantRawKey{}).(bool)
+	return want
+}
+
+// Parser is implemented by sources that can run their extraction again over
+// a page kept in Page.Raw by an earlier Search.
+type Parser interface {
+	ParseRaw(ctx context.Context, raw []byte) (Page, error)
+}
+
+// Source is a retailer the Engine can crawl. Implementations only fetch and
+// parse; keyword loading and persistence are handled by the Engine, and
+// retries by the httpx transport the source is built with.
+type Source interface {
+	Name() string
+	Search(ctx context.Context, keyword string, cursor string) (Page, error)
+}
@@ -0,0 +1,271 @@
+package amazon
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocolly/colly"
+	"github.com/mindsgn-studio/takealot-scraper/internal/httpx"
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"github.com/mindsgn-studio/takealot-scraper/internal/scraper"
+)
+
+const (
+	Name             = "amazon"
+	Host             = "amazon.co.za"
+	PriceDedupWindow = 2 * time.Hour
+	// RequestsPerSecond is the default request budget for Host.
+	RequestsPerSecond = 1
+	// DefaultHTTPTimeout bounds each attempt at a request; the transport
+	// applies it so retries are not cut short.
+	DefaultHTTPTimeout = 10 * time.Second
+	UserAgent          = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+)
+
+// Reasons a search result card is skipped.
+const (
+	SkipNoASIN scraper.SkipReason = "no data-asin"
+)
+
+var priceRe = regexp.MustCompile(`R[ \xA0]?([\d \xA0]+,\d{2})`)
+
+type Source struct {
+	transport http.RoundTripper
+	userAgent string
+	timeout   time.Duration
+}
+
+// Defaults are the settings Amazon is crawled with unless configured
+// otherwise.
+func Defaults() model.SourceConfig {
+	return model.SourceConfig{
+		UserAgent:         UserAgent,
+		RequestsPerSecond: RequestsPerSecond,
+		Timeout:           DefaultHTTPTimeout,
+		PriceDedupWindow:  PriceDedupWindow,
+	}
+}
+
+// New returns a Amazon source configured by cfg. A nil transport uses
+// http.DefaultTransport bounded by cfg.Timeout; a transport given is
+// expected to bound each attempt itself, see httpx.Transport.SetAttemptTimeout.
+func New(cfg model.SourceConfig, transport http.RoundTripper) *Source {
+	return &Source{transport: transport, userAgent: cfg.UserAgent, timeout: cfg.Timeout}
+}
+
+// ParseRaw runs the search page handlers over a page archived from an
+// earlier Search by serving it in place of the network.
+func (s *Source) ParseRaw(ctx context.Context, raw []byte) (scraper.Page, error) {
+	archived := &Source{transport: httpx.StaticBody(raw, "text/html; charset=utf-8")}
+	return archived.Search(ctx, "", "")
+}
+
+// newCollector returns a collector that stops fetching once ctx is done,
+// such as when the scrape is cancelled or the keyword's lease is lost.
+func (s *Source) newCollector(ctx context.Context) *colly.Collector {
+	collyClient := colly.NewCollector()
+	collyClient.OnRequest(func(r *colly.Request) {
+		if ctx.Err() != nil {
+			r.Abort()
+		}
+	})
+	collyClient.UserAgent = UserAgent
+	if s.userAgent != "" {
+		collyClient.UserAgent = s.userAgent
+	}
+	if s.transport != nil {
+		// colly's client timeout would span every retry the transport
+		// makes, so attempts are left for the transport to bound.
+		collyClient.SetRequestTimeout(0)
+	} else if s.timeout > 0 {
+		collyClient.SetRequestTimeout(s.timeout)
+	}
+	// colly sends its requests without a context; binding ctx lets a
+	// cancelled scrape abort the one in flight too.
+	collyClient.WithTransport(httpx.BindContext(ctx, s.transport))
+	return collyClient
+}
+
+func (s *Source) Name() string {
+	return Name
+}
+
+func (s *Source) Search(ctx context.Context, keyword string, cursor string) (scraper.Page, error) {
+	page := 1
+	if cursor != "" {
+		n, err := strconv.Atoi(cursor)
+		if err != nil {
+			return scraper.Page{}, fmt.Errorf("invalid cursor %q: %w", cursor, err)
+		}
+		page = n
+	}
+
+	var listings []scraper.Listing
+	var skipped []scraper.Skipped
+	var body []byte
+	containerFound := false
+	cards := 0
+	totalPages := 0
+	hasNext := false
+
+	collyClient := s.newCollector(ctx)
+
+	collyClient.OnResponse(func(r *colly.Response) {
+		body = r.Body
+	})
+
+	collyClient.OnHTML("div.s-result-list.s-search-results.sg-row", func(h *colly.HTMLElement) {
+		containerFound = true
+		h.ForEach("div.sg-col-4-of-24.sg-col-4-of-12.s-result-item.s-asin.sg-col-4-of-16.sg-col.s-widget-spacing-small.sg-col-4-of-20", func(_ int, cardElement *colly.HTMLElement) {
+			cards++
+			listing := scraper.Listing{
+				ID:    cardElement.Attr("data-asin"),
+				Title: cardElement.ChildText("h2.a-size-base-plus.a-color-base.a-text-normal"),
+			}
+
+			cardElement.ForEach("a.a-link-normal.s-no-outline", func(_ int, h *colly.HTMLElement) {
+				listing.Link = "https://www.amazon.co.za" + h.Attr("href")
+			})
+
+			text := cardElement.ChildText("span.a-offscreen")
+			listing.Price, _ = ExtractPrice(text)
+			listing.ListPrice, _ = ExtractPrice(cardElement.ChildText("span.a-price.a-text-price span.a-offscreen"))
+			listing.Availability, listing.LeadTime = cardAvailability(cardElement, listing.Price)
+
+			cardElement.ForEach("img.s-image", func(_ int, h *colly.HTMLElement) {
+				listing.Images = append(listing.Images, h.Attr("src"))
+			})
+
+			if listing.ID == "" {
+				html, _ := cardElement.DOM.Html()
+				skipped = append(skipped, scraper.Skipped{Reason: SkipNoASIN, Sample: scraper.Sample([]byte(html))})
+				return
+			}
+			listings = append(listings, listing)
+		})
+
+		h.ForEach("span.s-pagination-item.s-pagination-disabled", func(_ int, h *colly.HTMLElement) {
+			if h.Text != "Previous" {
+				number, err := strconv.Atoi(h.Text)
+				if err != nil {
+					return
+				}
+				if number > totalPages {
+					totalPages = number
+				}
+			}
+		})
+
+		if h.DOM.Find("a.s-pagination-next").Length() > 0 {
+			hasNext = true
+		}
+	})
+
+	link := fmt.Sprintf("https://www.amazon.co.za/s?k=%s&page=%d", url.QueryEscape(keyword), page)
+	if err := collyClient.Visit(link); err != nil {
+		return scraper.Page{}, fmt.Errorf("visit %s: %w", link, err)
+	}
+	collyClient.Wait()
+	if err := ctx.Err(); err != nil {
+		return scraper.Page{}, err
+	}
+
+	switch {
+	case !containerFound:
+		return scraper.Page{}, schemaError(ctx, "search results container not found", body)
+	case cards == 0:
+		return scraper.Page{}, schemaError(ctx, "zero product cards", body)
+	}
+
+	next := ""
+	if hasNext || page < totalPages {
+		next = strconv.Itoa(page + 1)
+	}
+	result := scraper.Page{Listings: listings, Skipped: skipped, Next: next}
+	if scraper.WantRaw(ctx) {
+		result.Raw = body
+	}
+	return result, nil
+}
+
+// ProductPrice loads a single product page and returns the buy box price,
+// with the struck through list price when the product is on sale and the
+// availability line. A page without a price that still says whether the
+// product is in stock, as when it is sold out, returns a zero price.
+func (s *Source) ProductPrice(ctx context.Context, link string) (model.Price, error) {
+	var price model.Price
+	list := 0.0
+	found := false
+
+	collyClient := s.newCollector(ctx)
+
+	collyClient.OnHTML("body", func(body *colly.HTMLElement) {
+		body.ForEach("div.a-section.a-spacing-none.aok-align-center.aok-relative", func(_ int, element *colly.HTMLElement) {
+			if found {
+				return
+			}
+			if p, err := ExtractPrice(element.Text); err == nil {
+				price.Price = p
+				found = true
+			}
+		})
+		list, _ = ExtractPrice(body.ChildText("#corePriceDisplay_desktop_feature_div span.a-price.a-text-price span.a-offscreen"))
+		price.Availability, price.LeadTime = scraper.ParseAvailability(body.ChildText("#availability"))
+	})
+
+	if err := collyClient.Visit(link); err != nil {
+		return model.Price{}, fmt.Errorf("visit %s: %w", link, err)
+	}
+	collyClient.Wait()
+	if err := ctx.Err(); err != nil {
+		return model.Price{}, err
+	}
+
+	if !found {
+		if price.Availability != model.AvailabilityUnknown {
+			return price, nil
+		}
+		return model.Price{}, fmt.Errorf("no price found on %s", link)
+	}
+	price.SetListPrice(list)
+	return price, nil
+}
+
+// cardAvailability reads a search result's stock note, such as "Only 2 left
+// in stock". Results with a price and no note are in stock.
+func cardAvailability(card *colly.HTMLElement, price float64) (model.Availability, string) {
+	status, leadTime := scraper.ParseAvailability(card.ChildText("[data-cy=availability-recipe]"))
+	if status == model.AvailabilityUnknown && price > 0 {
+		return model.InStock, ""
+	}
+	return status, leadTime
+}
+
+func schemaError(ctx context.Context, reason string, body []byte) *scraper.SchemaError {
+	err := &scraper.SchemaError{Reason: reason, Sample: scraper.Sample(body)}
+	if scraper.WantRaw(ctx) {
+		err.Raw = body
+	}
+	return err
+}
+
+func ExtractPrice(text string) (float64, error) {
+	match := priceRe.FindStringSubmatch(text)
+	if len(match) < 2 {
+		return 0, fmt.Errorf("No price found")
+	}
+	clean := strings.ReplaceAll(match[1], " ", "")
+	clean = strings.ReplaceAll(clean, "\u00A0", "")
+	clean = strings.Replace(clean, ",", ".", 1)
+	price, err := strconv.ParseFloat(clean, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Error parsing price")
+	}
+	return price, nil
+}
@@ -0,0 +1,192 @@
+package amazon
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/httpx"
+	"github.com/mindsgn-studio/takealot-scraper/internal/httpx/httpxtest"
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"github.com/mindsgn-studio/takealot-scraper/internal/scraper"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	raw, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestParseRaw(t *testing.T) {
+	page, err := New(Defaults(), nil).ParseRaw(context.Background(), readFixture(t, "search.html"))
+	if err != nil {
+		t.Fatalf("ParseRaw: %v", err)
+	}
+
+	want := []scraper.Listing{
+		{
+			ID:           "B0A1KETTLE",
+			Title:        "Defy 1.7l Cordless Kettle",
+			Link:         "https://www.amazon.co.za/Defy-Cordless-Kettle/dp/B0A1KETTLE/ref=sr_1_1",
+			Images:       []string{"https://m.media-amazon.com/images/I/61a1.jpg"},
+			Price:        299,
+			ListPrice:    399,
+			Availability: model.LimitedStock,
+		},
+		{
+			ID:           "B0A2KETTLE",
+			Title:        "Bosch Variable Temperature Kettle",
+			Link:         "https://www.amazon.co.za/Bosch-Kettle/dp/B0A2KETTLE/ref=sr_1_2",
+			Images:       []string{"https://m.media-amazon.com/images/I/61a2.jpg"},
+			Price:        1499,
+			Availability: model.InStock,
+		},
+		{
+			ID:           "B0A4KETTLE",
+			Title:        "Smeg Retro Kettle",
+			Link:         "https://www.amazon.co.za/Smeg-Kettle/dp/B0A4KETTLE/ref=sr_1_4",
+			Images:       []string{"https://m.media-amazon.com/images/I/61a4.jpg"},
+			Availability: model.OutOfStock,
+		},
+	}
+	if !reflect.DeepEqual(page.Listings, want) {
+		t.Errorf("listings:\n got %+v\nwant %+v", page.Listings, want)
+	}
+	if len(page.Skipped) != 1 || page.Skipped[0].Reason != SkipNoASIN {
+		t.Errorf("skipped = %+v, want one %q", page.Skipped, SkipNoASIN)
+	}
+	if page.Next != "2" {
+		t.Errorf("Next = %q, want %q", page.Next, "2")
+	}
+}
+
+func TestParseRawSchemaError(t *testing.T) {
+	tests := []struct {
+		name   string
+		raw    []byte
+		reason string
+	}{
+		{"captcha page", readFixture(t, "captcha.html"), "search results container not found"},
+		{"no cards", []byte(`<html><body><div class="s-result-list s-search-results sg-row"></div></body></html>`), "zero product cards"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(Defaults(), nil).ParseRaw(scraper.WithRaw(context.Background()), tt.raw)
+			var schemaErr *scraper.SchemaError
+			if !errors.As(err, &schemaErr) {
+				t.Fatalf("ParseRaw error = %v, want *scraper.SchemaError", err)
+			}
+			if schemaErr.Reason != tt.reason {
+				t.Errorf("Reason = %q, want %q", schemaErr.Reason, tt.reason)
+			}
+			if string(schemaErr.Raw) != string(tt.raw) {
+				t.Error("Raw is not the whole page")
+			}
+		})
+	}
+}
+
+func TestProductPrice(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture string
+		want    model.Price
+	}{
+		{
+			name:    "on sale",
+			fixture: "product.html",
+			want:    model.Price{Price: 249, ListPrice: 299, Savings: 50, DiscountPct: 16.72, Availability: model.InStock},
+		},
+		{
+			name:    "sold out",
+			fixture: "product_unavailable.html",
+			want:    model.Price{Availability: model.OutOfStock},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(Defaults(), httpx.StaticBody(readFixture(t, tt.fixture), "text/html; charset=utf-8"))
+			got, err := s.ProductPrice(context.Background(), "https://www.amazon.co.za/dp/B0A1KETTLE")
+			if err != nil {
+				t.Fatalf("ProductPrice: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("price = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductPriceMissing(t *testing.T) {
+	s := New(Defaults(), httpx.StaticBody(readFixture(t, "captcha.html"), "text/html; charset=utf-8"))
+	if _, err := s.ProductPrice(context.Background(), "https://www.amazon.co.za/dp/B0A1KETTLE"); err == nil {
+		t.Error("ProductPrice of a page without a price or availability succeeded")
+	}
+}
+
+func TestExtractPrice(t *testing.T) {
+	tests := []struct {
+		text string
+		want float64
+		ok   bool
+	}{
+		{"R299,00", 299, true},
+		{"R 299,00", 299, true},
+		{"R 1 499,00", 1499, true},
+		{"R 12 499,95", 12499.95, true},
+		{"R299,00R399,00", 299, true},
+		{"", 0, false},
+		{"Currently unavailable.", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := ExtractPrice(tt.text)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ExtractPrice(%q) = %v, %v; want %v, ok %v", tt.text, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestSearchCancelled(t *testing.T) {
+	transport := &httpxtest.Counter{RoundTripper: httpx.StaticBody(readFixture(t, "search.html"), "text/html; charset=utf-8")}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := New(Defaults(), transport).Search(ctx, "kettle", "")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Search error = %v, want context.Canceled", err)
+	}
+	if n := transport.Requests(); n != 0 {
+		t.Errorf("%d requests sent after cancellation", n)
+	}
+}
+
+func TestSearchCancelledInFlight(t *testing.T) {
+	started := make(chan *http.Request, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := New(Defaults(), httpxtest.Hang(started)).Search(ctx, "kettle", "")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Search error = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Search still waiting on its request after cancellation")
+	}
+}
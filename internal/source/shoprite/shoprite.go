@@ -0,0 +1,201 @@
+package shoprite
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocolly/colly"
+	"github.com/mindsgn-studio/takealot-scraper/internal/httpx"
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"github.com/mindsgn-studio/takealot-scraper/internal/scraper"
+)
+
+const (
+	Name             = "shoprite"
+	Host             = "shoprite.co.za"
+	PriceDedupWindow = 2 * time.Hour
+	// RequestsPerSecond is the default request budget for Host.
+	RequestsPerSecond = 1
+	// DefaultHTTPTimeout bounds each attempt at a request; the transport
+	// applies it so retries are not cut short.
+	DefaultHTTPTimeout = 10 * time.Second
+	UserAgent          = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+)
+
+// Reasons a search result card is skipped.
+const (
+	SkipNoProductCode scraper.SkipReason = "no data-product-code"
+)
+
+type Source struct {
+	transport http.RoundTripper
+	userAgent string
+	timeout   time.Duration
+}
+
+// Defaults are the settings Shoprite is crawled with unless configured
+// otherwise.
+func Defaults() model.SourceConfig {
+	return model.SourceConfig{
+		UserAgent:         UserAgent,
+		RequestsPerSecond: RequestsPerSecond,
+		Timeout:           DefaultHTTPTimeout,
+		PriceDedupWindow:  PriceDedupWindow,
+	}
+}
+
+// New returns a Shoprite source configured by cfg. A nil transport uses
+// http.DefaultTransport bounded by cfg.Timeout; a transport given is
+// expected to bound each attempt itself, see httpx.Transport.SetAttemptTimeout.
+func New(cfg model.SourceConfig, transport http.RoundTripper) *Source {
+	return &Source{transport: transport, userAgent: cfg.UserAgent, timeout: cfg.Timeout}
+}
+
+// ParseRaw runs the search page handlers over a page archived from an
+// earlier Search by serving it in place of the network.
+func (s *Source) ParseRaw(ctx context.Context, raw []byte) (scraper.Page, error) {
+	archived := &Source{transport: httpx.StaticBody(raw, "text/html; charset=utf-8")}
+	return archived.Search(ctx, "", "")
+}
+
+// newCollector returns a collector that stops fetching once ctx is done,
+// such as when the scrape is cancelled or the keyword's lease is lost.
+func (s *Source) newCollector(ctx context.Context) *colly.Collector {
+	collyClient := colly.NewCollector()
+	collyClient.OnRequest(func(r *colly.Request) {
+		if ctx.Err() != nil {
+			r.Abort()
+		}
+	})
+	collyClient.UserAgent = UserAgent
+	if s.userAgent != "" {
+		collyClient.UserAgent = s.userAgent
+	}
+	if s.transport != nil {
+		// colly's client timeout would span every retry the transport
+		// makes, so attempts are left for the transport to bound.
+		collyClient.SetRequestTimeout(0)
+	} else if s.timeout > 0 {
+		collyClient.SetRequestTimeout(s.timeout)
+	}
+	// colly sends its requests without a context; binding ctx lets a
+	// cancelled scrape abort the one in flight too.
+	collyClient.WithTransport(httpx.BindContext(ctx, s.transport))
+	return collyClient
+}
+
+func (s *Source) Name() string {
+	return Name
+}
+
+// Search only fetches the first results page; Shoprite pagination is not
+// followed yet.
+func (s *Source) Search(ctx context.Context, keyword string, cursor string) (scraper.Page, error) {
+	page := 0
+	if cursor != "" {
+		n, err := strconv.Atoi(cursor)
+		if err != nil {
+			return scraper.Page{}, fmt.Errorf("invalid cursor %q: %w", cursor, err)
+		}
+		page = n
+	}
+
+	var listings []scraper.Listing
+	var skipped []scraper.Skipped
+	var body []byte
+	containerFound := false
+	cards := 0
+
+	collyClient := s.newCollector(ctx)
+
+	collyClient.OnResponse(func(r *colly.Response) {
+		body = r.Body
+	})
+
+	collyClient.OnHTML("div.search-landing__block__list.col-sm-12.col-md-9", func(h *colly.HTMLElement) {
+		containerFound = true
+		h.ForEach("div.item-product", func(_ int, cardElement *colly.HTMLElement) {
+			cards++
+			listing := scraper.Listing{
+				Title: cardElement.ChildText("a.product-listening-click"),
+			}
+
+			cardElement.ForEach("img", func(_ int, imageTag *colly.HTMLElement) {
+				listing.Images = append(listing.Images, "https://www.shoprite.co.za"+imageTag.Attr("src"))
+			})
+
+			priceText := cardElement.ChildText("span.now")
+			listing.Price, _ = extractPrice(priceText)
+			listing.ListPrice, _ = extractPrice(cardElement.ChildText("span.was"))
+			listing.Availability = cardAvailability(cardElement)
+
+			cardElement.ForEach("a.product-listening-click", func(_ int, hrefTag *colly.HTMLElement) {
+				listing.Link = "https://www.shoprite.co.za" + hrefTag.Attr("href")
+			})
+
+			cardElement.ForEach("form.js-promo-alerts-product-form", func(_ int, h *colly.HTMLElement) {
+				listing.ID = h.Attr("data-product-code")
+			})
+
+			if listing.ID == "" {
+				html, _ := cardElement.DOM.Html()
+				skipped = append(skipped, scraper.Skipped{Reason: SkipNoProductCode, Sample: scraper.Sample([]byte(html))})
+				return
+			}
+			listings = append(listings, listing)
+		})
+	})
+
+	link := fmt.Sprintf("https://www.shoprite.co.za/search/all?q=%s&page=%d", url.QueryEscape(keyword), page)
+	if err := collyClient.Visit(link); err != nil {
+		return scraper.Page{}, fmt.Errorf("visit %s: %w", link, err)
+	}
+	collyClient.Wait()
+	if err := ctx.Err(); err != nil {
+		return scraper.Page{}, err
+	}
+
+	switch {
+	case !containerFound:
+		return scraper.Page{}, schemaError(ctx, "search results container not found", body)
+	case cards == 0:
+		return scraper.Page{}, schemaError(ctx, "zero product cards", body)
+	}
+
+	result := scraper.Page{Listings: listings, Skipped: skipped}
+	if scraper.WantRaw(ctx) {
+		result.Raw = body
+	}
+	return result, nil
+}
+
+func schemaError(ctx context.Context, reason string, body []byte) *scraper.SchemaError {
+	err := &scraper.SchemaError{Reason: reason, Sample: scraper.Sample(body)}
+	if scraper.WantRaw(ctx) {
+		err.Raw = body
+	}
+	return err
+}
+
+// cardAvailability reads a product card's out of stock badge. Shoprite
+// shows no other stock levels, so cards without one are in stock.
+func cardAvailability(card *colly.HTMLElement) model.Availability {
+	if card.DOM.Find("[class*=out-of-stock]").Length() > 0 {
+		return model.OutOfStock
+	}
+	return model.InStock
+}
+
+func extractPrice(text string) (float64, error) {
+	clean := strings.TrimSpace(strings.ReplaceAll(text, "R", ""))
+	price, err := strconv.ParseFloat(clean, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Error parsing price")
+	}
+	return price, nil
+}
@@ -0,0 +1,122 @@
+package shoprite
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/httpx"
+	"github.com/mindsgn-studio/takealot-scraper/internal/httpx/httpxtest"
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"github.com/mindsgn-studio/takealot-scraper/internal/scraper"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	raw, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestParseRaw(t *testing.T) {
+	page, err := New(Defaults(), nil).ParseRaw(context.Background(), readFixture(t, "search.html"))
+	if err != nil {
+		t.Fatalf("ParseRaw: %v", err)
+	}
+
+	want := []scraper.Listing{
+		{
+			ID:           "10146925EA",
+			Title:        "Defy Cordless Kettle 1.7L",
+			Link:         "https://www.shoprite.co.za/All-Departments/Home/Kettles/Defy-Cordless-Kettle-1-7L/p/10146925EA",
+			Images:       []string{"https://www.shoprite.co.za/medias/10146925EA-checkers300Wx300H.png"},
+			Price:        299.99,
+			ListPrice:    349.99,
+			Availability: model.InStock,
+		},
+		{
+			ID:           "10556233EA",
+			Title:        "Russell Hobbs Kettle",
+			Link:         "https://www.shoprite.co.za/All-Departments/Home/Kettles/Russell-Hobbs-Kettle/p/10556233EA",
+			Images:       []string{"https://www.shoprite.co.za/medias/10556233EA-checkers300Wx300H.png"},
+			Price:        499,
+			Availability: model.OutOfStock,
+		},
+	}
+	if !reflect.DeepEqual(page.Listings, want) {
+		t.Errorf("listings:\n got %+v\nwant %+v", page.Listings, want)
+	}
+	if len(page.Skipped) != 1 || page.Skipped[0].Reason != SkipNoProductCode {
+		t.Errorf("skipped = %+v, want one %q", page.Skipped, SkipNoProductCode)
+	}
+	if page.Next != "" {
+		t.Errorf("Next = %q, want none", page.Next)
+	}
+}
+
+func TestParseRawSchemaError(t *testing.T) {
+	tests := []struct {
+		name   string
+		raw    []byte
+		reason string
+	}{
+		{"no results", readFixture(t, "empty.html"), "zero product cards"},
+		{"redesigned page", []byte(`<html><body><div class="plp-grid"></div></body></html>`), "search results container not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(Defaults(), nil).ParseRaw(context.Background(), tt.raw)
+			var schemaErr *scraper.SchemaError
+			if !errors.As(err, &schemaErr) {
+				t.Fatalf("ParseRaw error = %v, want *scraper.SchemaError", err)
+			}
+			if schemaErr.Reason != tt.reason {
+				t.Errorf("Reason = %q, want %q", schemaErr.Reason, tt.reason)
+			}
+		})
+	}
+}
+
+func TestSearchCancelled(t *testing.T) {
+	transport := &httpxtest.Counter{RoundTripper: httpx.StaticBody(readFixture(t, "search.html"), "text/html; charset=utf-8")}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := New(Defaults(), transport).Search(ctx, "kettle", "")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Search error = %v, want context.Canceled", err)
+	}
+	if n := transport.Requests(); n != 0 {
+		t.Errorf("%d requests sent after cancellation", n)
+	}
+}
+
+func TestSearchCancelledInFlight(t *testing.T) {
+	started := make(chan *http.Request, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := New(Defaults(), httpxtest.Hang(started)).Search(ctx, "kettle", "")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Search error = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Search still waiting on its request after cancellation")
+	}
+}
@@ -0,0 +1,290 @@
+package takealot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"github.com/mindsgn-studio/takealot-scraper/internal/scraper"
+)
+
+// The types below model the parts of the v-1-14-0 search response we use:
+//
+//	sections.products.results[].product_views.{core,gallery,buybox_summary,enhanced_ecommerce_click}
+//	sections.products.results[].product_views.buybox_summary.{prices,listing_price,is_preorder}
+//	sections.products.results[].product_views.stock_availability_summary.{status,is_leadtime}
+//	sections.products.paging.next_is_after
+
+type searchPaging struct {
+	NextIsAfter string `json:"next_is_after"`
+}
+
+type searchResult struct {
+	ProductViews *productViews `json:"product_views"`
+}
+
+type productViews struct {
+	Core          *productCore       `json:"core"`
+	Gallery       *productGallery    `json:"gallery"`
+	BuyboxSummary *buyboxSummary     `json:"buybox_summary"`
+	Stock         *stockSummary      `json:"stock_availability_summary"`
+	Click         *enhancedEcommerce `json:"enhanced_ecommerce_click"`
+}
+
+type productCore struct {
+	Title string `json:"title"`
+	Brand string `json:"brand"`
+	Slug  string `json:"slug"`
+}
+
+type productGallery struct {
+	Images *stringList `json:"images"`
+}
+
+type buyboxSummary struct {
+	Prices       json.RawMessage `json:"prices"`
+	ListingPrice json.RawMessage `json:"listing_price"`
+	IsPreorder   bool            `json:"is_preorder"`
+}
+
+// stockSummary is the stock line under the price, such as "In stock" or
+// "Ships in 5 - 7 work days"; IsLeadtime marks stock ordered from a
+// supplier.
+type stockSummary struct {
+	Status     string `json:"status"`
+	IsLeadtime bool   `json:"is_leadtime"`
+}
+
+type enhancedEcommerce struct {
+	Ecommerce struct {
+		Click struct {
+			Products productRefs `json:"products"`
+		} `json:"click"`
+	} `json:"ecommerce"`
+}
+
+// stringList accepts either a list of strings or a single string. Entries
+// of any other type are ignored.
+type stringList []string
+
+func (l *stringList) UnmarshalJSON(data []byte) error {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	switch v := raw.(type) {
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, it := range v {
+			if s, ok := it.(string); ok {
+				out = append(out, s)
+			}
+		}
+		*l = out
+	case string:
+		*l = []string{v}
+	default:
+		*l = []string{}
+	}
+	return nil
+}
+
+// productRefs holds the product IDs from the click payload, which is either
+// a list of products or a single one.
+type productRefs []string
+
+func (r *productRefs) UnmarshalJSON(data []byte) error {
+	type ref struct {
+		ID interface{} `json:"id"`
+	}
+	var refs []ref
+	if err := json.Unmarshal(data, &refs); err != nil {
+		var one ref
+		if err := json.Unmarshal(data, &one); err != nil {
+			return nil
+		}
+		refs = []ref{one}
+	}
+	for _, p := range refs {
+		if id, ok := p.ID.(string); ok && id != "" {
+			*r = append(*r, id)
+		}
+	}
+	return nil
+}
+
+// price returns the first of buybox_summary.prices, which is usually a
+// list of numbers but has been seen as a bare number or a string. Further
+// prices belong to other variants and are ignored.
+func (b *buyboxSummary) price() (float64, error) {
+	if len(b.Prices) == 0 || string(b.Prices) == "null" {
+		return 0, errors.New("no prices")
+	}
+
+	var list []json.RawMessage
+	value := b.Prices
+	if err := json.Unmarshal(b.Prices, &list); err == nil {
+		if len(list) == 0 {
+			return 0, errors.New("empty prices array")
+		}
+		value = list[0]
+	}
+	return parsePrice(value)
+}
+
+// listPrice returns buybox_summary.listing_price, the price shown struck
+// through, or zero when there is none or it cannot be read.
+func (b *buyboxSummary) listPrice() float64 {
+	if len(b.ListingPrice) == 0 || string(b.ListingPrice) == "null" {
+		return 0
+	}
+	price, err := parsePrice(b.ListingPrice)
+	if err != nil {
+		return 0
+	}
+	return price
+}
+
+// availability reads the stock line, letting the buy box's pre-order flag
+// and the lead time flag override its text.
+func availability(buybox *buyboxSummary, stock *stockSummary) (model.Availability, string) {
+	if buybox.IsPreorder {
+		return model.PreOrder, ""
+	}
+	if stock == nil {
+		return model.AvailabilityUnknown, ""
+	}
+	status, leadTime := scraper.ParseAvailability(stock.Status)
+	if stock.IsLeadtime && status != model.OutOfStock && status != model.PreOrder {
+		return model.LeadTime, strings.TrimSpace(stock.Status)
+	}
+	return status, leadTime
+}
+
+// parsePrice reads a price given as a number or a string.
+func parsePrice(value json.RawMessage) (float64, error) {
+	var n float64
+	if err := json.Unmarshal(value, &n); err == nil {
+		return n, nil
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		return strconvParseFloat(s)
+	}
+	return 0, fmt.Errorf("unsupported price %s", value)
+}
+
+// decodeSearch streams a search response, handing each result to fn as it
+// is read so the whole page is never held as a generic tree.
+func decodeSearch(r io.Reader, fn func(json.RawMessage)) (searchPaging, error) {
+	var paging searchPaging
+	dec := json.NewDecoder(r)
+	seenResults := false
+
+	err := walkObject(dec, func(key string) error {
+		if key != "sections" {
+			return skipValue(dec)
+		}
+		return walkObject(dec, func(key string) error {
+			if key != "products" {
+				return skipValue(dec)
+			}
+			return walkObject(dec, func(key string) error {
+				switch key {
+				case "paging":
+					return dec.Decode(&paging)
+				case "results":
+					seenResults = true
+					return walkArray(dec, func() error {
+						var raw json.RawMessage
+						if err := dec.Decode(&raw); err != nil {
+							return err
+						}
+						fn(raw)
+						return nil
+					})
+				default:
+					return skipValue(dec)
+				}
+			})
+		})
+	})
+	if err != nil {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		if errors.Is(err, errShape) || errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+			return searchPaging{}, &scraper.SchemaError{Reason: err.Error()}
+		}
+		return searchPaging{}, fmt.Errorf("decode json: %w", err)
+	}
+	if !seenResults {
+		return searchPaging{}, &scraper.SchemaError{Reason: "sections.products.results missing"}
+	}
+	return paging, nil
+}
+
+// errShape marks JSON that is valid but not laid out as expected.
+var errShape = errors.New("unexpected shape")
+
+// walkObject reads a JSON object from dec, calling fn with each key while
+// dec is positioned at that key's value. fn must consume the value.
+func walkObject(dec *json.Decoder, fn func(key string) error) error {
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("%w: object key %v", errShape, tok)
+		}
+		if err := fn(key); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	_, err := dec.Token()
+	return err
+}
+
+func walkArray(dec *json.Decoder, fn func() error) error {
+	if err := expectDelim(dec, '['); err != nil {
+		return err
+	}
+	for dec.More() {
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+	_, err := dec.Token()
+	return err
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != want {
+		return fmt.Errorf("%w: expected %q, got %v", errShape, want, tok)
+	}
+	return nil
+}
+
+func skipValue(dec *json.Decoder) error {
+	var discard json.RawMessage
+	return dec.Decode(&discard)
+}
+
+func zoomImages(images []string) []string {
+	out := make([]string, len(images))
+	for i, image := range images {
+		out[i] = strings.ReplaceAll(image, "{size}", "zoom")
+	}
+	return out
+}
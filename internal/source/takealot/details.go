@@ -0,0 +1,240 @@
+package takealot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/httpx"
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"github.com/mindsgn-studio/takealot-scraper/internal/scraper"
+	"golang.org/x/net/html"
+)
+
+// The types below model the parts of the v-1-14-0 product-details response
+// we use:
+//
+//	core.{star_rating,reviews}
+//	description.html
+//	breadcrumbs.items[].name
+//	product_information.items[].{id,display_name,value}
+//	reviews.{star_rating,count}
+
+type detailResponse struct {
+	Core *struct {
+		StarRating *float64 `json:"star_rating"`
+		Reviews    *int     `json:"reviews"`
+	} `json:"core"`
+	Description *struct {
+		HTML string `json:"html"`
+	} `json:"description"`
+	Breadcrumbs *struct {
+		Items []struct {
+			Name string `json:"name"`
+		} `json:"items"`
+	} `json:"breadcrumbs"`
+	ProductInformation *struct {
+		Items []infoItem `json:"items"`
+	} `json:"product_information"`
+	Reviews *struct {
+		StarRating float64 `json:"star_rating"`
+		Count      int     `json:"count"`
+	} `json:"reviews"`
+}
+
+// infoItem is one row of the product information table. Its value is a
+// string, a number, a list, or an object with a name.
+type infoItem struct {
+	ID          string          `json:"id"`
+	DisplayName string          `json:"display_name"`
+	Value       json.RawMessage `json:"value"`
+}
+
+// Details fetches the product page of plid, the listing ID Search returns.
+func (s *Source) Details(ctx context.Context, plid string) (model.Details, error) {
+	apiURL := fmt.Sprintf("https://api.takealot.com/rest/v-1-14-0/product-details/PLID%s?platform=desktop", url.PathEscape(plid))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return model.Details{}, fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("User-Agent", s.userAgent)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return model.Details{}, err
+	}
+	if err := httpx.CheckResponse(resp); err != nil {
+		return model.Details{}, err
+	}
+	defer httpx.DrainBody(resp.Body)
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return model.Details{}, fmt.Errorf("read body: %w", err)
+	}
+	d, err := ParseDetails(bytes.NewReader(body))
+	var schemaErr *scraper.SchemaError
+	if errors.As(err, &schemaErr) {
+		schemaErr.Sample = scraper.Sample(body)
+	}
+	if err != nil {
+		return model.Details{}, err
+	}
+	d.Source = Name
+	d.SourceID = plid
+	return d, nil
+}
+
+// ParseDetails reads a product-details response. A response without the
+// core block is a *scraper.SchemaError.
+func ParseDetails(r io.Reader) (model.Details, error) {
+	var resp detailResponse
+	if err := json.NewDecoder(r).Decode(&resp); err != nil {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+			return model.Details{}, &scraper.SchemaError{Reason: err.Error()}
+		}
+		return model.Details{}, fmt.Errorf("decode json: %w", err)
+	}
+	if resp.Core == nil {
+		return model.Details{}, &scraper.SchemaError{Reason: "core missing"}
+	}
+
+	var d model.Details
+	if resp.Core.StarRating != nil {
+		d.Rating = *resp.Core.StarRating
+	}
+	if resp.Core.Reviews != nil {
+		d.Reviews = *resp.Core.Reviews
+	}
+	if resp.Reviews != nil {
+		if d.Rating == 0 {
+			d.Rating = resp.Reviews.StarRating
+		}
+		if d.Reviews == 0 {
+			d.Reviews = resp.Reviews.Count
+		}
+	}
+	if resp.Description != nil {
+		d.Description = htmlText(resp.Description.HTML)
+	}
+	if resp.Breadcrumbs != nil {
+		for _, b := range resp.Breadcrumbs.Items {
+			if name := strings.TrimSpace(b.Name); name != "" {
+				d.Categories = append(d.Categories, name)
+			}
+		}
+	}
+	if resp.ProductInformation != nil {
+		for _, item := range resp.ProductInformation.Items {
+			addInfo(&d, item)
+		}
+	}
+	return d, nil
+}
+
+// addInfo files a product information row under the field it belongs to,
+// or under Specs.
+func addInfo(d *model.Details, item infoItem) {
+	values := infoValues(item.Value)
+	if len(values) == 0 {
+		return
+	}
+	name := strings.TrimSpace(item.DisplayName)
+	if name == "" {
+		name = item.ID
+	}
+	switch key := strings.ToLower(item.ID + " " + name); {
+	case strings.Contains(key, "barcode"), strings.Contains(key, "gtin"):
+		for _, v := range values {
+			for _, code := range strings.Split(v, ",") {
+				if code = strings.TrimSpace(code); code != "" {
+					d.Barcodes = append(d.Barcodes, code)
+				}
+			}
+		}
+	case strings.Contains(key, "warranty"):
+		d.Warranty = strings.Join(values, ", ")
+	case strings.Contains(key, "categor"):
+		if len(d.Categories) == 0 {
+			d.Categories = values
+		}
+	default:
+		if d.Specs == nil {
+			d.Specs = make(map[string]string)
+		}
+		d.Specs[name] = strings.Join(values, ", ")
+	}
+}
+
+// infoValues flattens a product information value to strings.
+func infoValues(raw json.RawMessage) []string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		if s = strings.TrimSpace(s); s != "" {
+			return []string{s}
+		}
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(raw, &n); err == nil {
+		return []string{n.String()}
+	}
+	var b bool
+	if err := json.Unmarshal(raw, &b); err == nil {
+		return []string{strconv.FormatBool(b)}
+	}
+	var list []json.RawMessage
+	if err := json.Unmarshal(raw, &list); err == nil {
+		var out []string
+		for _, v := range list {
+			out = append(out, infoValues(v)...)
+		}
+		return out
+	}
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &obj); err == nil {
+		for _, key := range []string{"name", "display_value", "value"} {
+			if v, ok := obj[key]; ok {
+				return infoValues(v)
+			}
+		}
+	}
+	return nil
+}
+
+// htmlText returns the text of an HTML fragment with block elements on
+// their own lines.
+func htmlText(fragment string) string {
+	var b strings.Builder
+	z := html.NewTokenizer(strings.NewReader(fragment))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			lines := strings.Split(b.String(), "\n")
+			out := lines[:0]
+			for _, line := range lines {
+				if line = strings.Join(strings.Fields(line), " "); line != "" {
+					out = append(out, line)
+				}
+			}
+			return strings.Join(out, "\n")
+		case html.TextToken:
+			b.Write(z.Text())
+		case html.StartTagToken, html.EndTagToken, html.SelfClosingTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "p", "br", "li", "div", "h1", "h2", "h3", "h4", "h5", "h6", "tr":
+				b.WriteByte('\n')
+			}
+		}
+	}
+}
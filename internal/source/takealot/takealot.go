@@ -0,0 +1,237 @@
+package takealot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/httpx"
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"github.com/mindsgn-studio/takealot-scraper/internal/scraper"
+)
+
+const (
+	Name               = "takealot"
+	Host               = "api.takealot.com"
+	PriceDedupWindow   = 1 * time.Hour
+	DefaultHTTPTimeout = 20 * time.Second
+	// RequestsPerSecond is the default request budget for Host.
+	RequestsPerSecond = 4
+)
+
+// Reasons a search result is skipped.
+const (
+	SkipMalformed     scraper.SkipReason = "malformed result"
+	SkipNoViews       scraper.SkipReason = "no product_views"
+	SkipMissingBlock  scraper.SkipReason = "missing core, gallery, buybox_summary or enhanced_ecommerce_click"
+	SkipNoImages      scraper.SkipReason = "no gallery images field"
+	SkipNoTitle       scraper.SkipReason = "no title"
+	SkipNoBrand       scraper.SkipReason = "no brand"
+	SkipNoSlug        scraper.SkipReason = "no slug"
+	SkipNoProductID   scraper.SkipReason = "no product id"
+	SkipUnusablePrice scraper.SkipReason = "no usable price"
+)
+
+type Source struct {
+	httpClient *http.Client
+	userAgent  string
+}
+
+// Defaults are the settings Takealot is crawled with unless configured
+// otherwise. The user agent is left to the global one.
+func Defaults() model.SourceConfig {
+	return model.SourceConfig{
+		RequestsPerSecond: RequestsPerSecond,
+		Timeout:           DefaultHTTPTimeout,
+		PriceDedupWindow:  PriceDedupWindow,
+	}
+}
+
+// New returns a Takealot source configured by cfg. A nil transport uses
+// http.DefaultTransport bounded by cfg.Timeout; a transport given is expected
+// to bound each attempt itself, see httpx.Transport.SetAttemptTimeout. A
+// client timeout would also cover every retry and Retry-After wait.
+func New(cfg model.SourceConfig, transport http.RoundTripper) *Source {
+	client := &http.Client{Transport: transport}
+	if transport == nil {
+		client.Timeout = cfg.Timeout
+		if client.Timeout <= 0 {
+			client.Timeout = DefaultHTTPTimeout
+		}
+	}
+	return &Source{
+		httpClient: client,
+		userAgent:  cfg.UserAgent,
+	}
+}
+
+func (s *Source) Name() string {
+	return Name
+}
+
+func (s *Source) Search(ctx context.Context, keyword string, cursor string) (scraper.Page, error) {
+	return s.FetchPage(ctx, keyword, cursor)
+}
+
+func (s *Source) FetchPage(ctx context.Context, item string, after string) (scraper.Page, error) {
+	escaped := url.QueryEscape(item)
+	apiURL := fmt.Sprintf("https://api.takealot.com/rest/v-1-14-0/searches/products?newsearch=true&qsearch=%s&track=1&userinit=true&searchbox=true", escaped)
+	if after != "" {
+		apiURL += "&after=" + url.QueryEscape(after)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return scraper.Page{}, fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("User-Agent", s.userAgent)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return scraper.Page{}, err
+	}
+	if err := httpx.CheckResponse(resp); err != nil {
+		return scraper.Page{}, err
+	}
+	defer httpx.DrainBody(resp.Body)
+
+	// The body is only held in full when the caller wants it archived.
+	var sample scraper.SampleWriter
+	var raw bytes.Buffer
+	tee := io.Writer(&sample)
+	if scraper.WantRaw(ctx) {
+		tee = io.MultiWriter(&sample, &raw)
+	}
+
+	page, err := Parse(io.TeeReader(resp.Body, tee))
+	var schemaErr *scraper.SchemaError
+	if errors.As(err, &schemaErr) {
+		schemaErr.Sample = sample.String()
+		if raw.Len() > 0 {
+			// Keep whatever the parser did not get round to reading.
+			_, _ = io.Copy(&raw, resp.Body)
+			schemaErr.Raw = raw.Bytes()
+		}
+	}
+	if err != nil {
+		return scraper.Page{}, err
+	}
+	if raw.Len() > 0 {
+		page.Raw = raw.Bytes()
+	}
+	return page, nil
+}
+
+// ParseRaw parses a search response archived from an earlier Search.
+func (s *Source) ParseRaw(_ context.Context, raw []byte) (scraper.Page, error) {
+	page, err := Parse(bytes.NewReader(raw))
+	var schemaErr *scraper.SchemaError
+	if errors.As(err, &schemaErr) {
+		schemaErr.Sample = scraper.Sample(raw)
+	}
+	return page, err
+}
+
+// Parse reads a search response. Results that cannot be turned into a
+// Listing are returned in Page.Skipped with the reason; a response that is
+// not laid out as expected is a *scraper.SchemaError.
+func Parse(r io.Reader) (scraper.Page, error) {
+	var page scraper.Page
+	paging, err := decodeSearch(r, func(raw json.RawMessage) {
+		listing, skipped, ok := parseResult(raw)
+		if ok {
+			page.Listings = append(page.Listings, listing)
+		} else {
+			page.Skipped = append(page.Skipped, skipped)
+		}
+	})
+	if err != nil {
+		return scraper.Page{}, err
+	}
+	page.Next = paging.NextIsAfter
+	return page, nil
+}
+
+func parseResult(raw json.RawMessage) (scraper.Listing, scraper.Skipped, bool) {
+	var result searchResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return scraper.Listing{}, scraper.Skipped{Reason: SkipMalformed, Sample: scraper.Sample(raw)}, false
+	}
+	if result.ProductViews == nil {
+		return scraper.Listing{}, scraper.Skipped{Reason: SkipNoViews, Sample: scraper.Sample(raw)}, false
+	}
+	listing, skipped, ok := extractItemData(result.ProductViews)
+	if !ok {
+		skipped.Sample = scraper.Sample(raw)
+	}
+	return listing, skipped, ok
+}
+
+func extractItemData(views *productViews) (scraper.Listing, scraper.Skipped, bool) {
+	skip := func(id string, reason scraper.SkipReason) (scraper.Listing, scraper.Skipped, bool) {
+		return scraper.Listing{}, scraper.Skipped{ID: id, Reason: reason}, false
+	}
+
+	id := ""
+	if views.Click != nil && len(views.Click.Ecommerce.Click.Products) > 0 {
+		id = views.Click.Ecommerce.Click.Products[0]
+	}
+	plid := strings.ReplaceAll(id, "PLID", "")
+
+	if views.Core == nil || views.Gallery == nil || views.BuyboxSummary == nil || views.Click == nil {
+		return skip(plid, SkipMissingBlock)
+	}
+	if views.Gallery.Images == nil {
+		return skip(plid, SkipNoImages)
+	}
+
+	core := views.Core
+	switch {
+	case core.Title == "":
+		return skip(plid, SkipNoTitle)
+	case core.Brand == "":
+		return skip(plid, SkipNoBrand)
+	case core.Slug == "":
+		return skip(plid, SkipNoSlug)
+	case id == "":
+		return skip(plid, SkipNoProductID)
+	}
+
+	// Sold out products may have no buy box price; they are kept so the
+	// item's availability is still updated.
+	status, leadTime := availability(views.BuyboxSummary, views.Stock)
+	price, err := views.BuyboxSummary.price()
+	if err != nil && status != model.OutOfStock {
+		return skip(plid, SkipUnusablePrice)
+	}
+
+	return scraper.Listing{
+		ID:           plid,
+		Title:        core.Title,
+		Brand:        core.Brand,
+		Link:         fmt.Sprintf("https://www.takealot.com/%s/%s", core.Slug, id),
+		Images:       zoomImages(*views.Gallery.Images),
+		Price:        price,
+		ListPrice:    views.BuyboxSummary.listPrice(),
+		Availability: status,
+		LeadTime:     leadTime,
+	}, scraper.Skipped{}, true
+}
+
+func strconvParseFloat(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, errors.New("empty price string")
+	}
+
+	s = strings.ReplaceAll(s, ",", "")
+	return strconv.ParseFloat(s, 64)
+}
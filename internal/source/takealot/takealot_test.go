@@ -0,0 +1,172 @@
+package takealot
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/httpx"
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"github.com/mindsgn-studio/takealot-scraper/internal/scraper"
+)
+
+func TestParse(t *testing.T) {
+	f, err := os.Open("testdata/search.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	page, err := Parse(f)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	want := []scraper.Listing{
+		{
+			ID:    "1001",
+			Title: "Defy 1.7l Cordless Kettle",
+			Brand: "Defy",
+			Link:  "https://www.takealot.com/defy-17l-cordless-kettle/PLID1001",
+			Images: []string{
+				"https://media.takealot.com/covers_images/a1/s-zoom.file",
+				"https://media.takealot.com/covers_images/a2/s-zoom.file",
+			},
+			Price:        299,
+			ListPrice:    399,
+			Availability: model.InStock,
+		},
+		{
+			ID:           "1002",
+			Title:        "Russell Hobbs Glass Kettle",
+			Brand:        "Russell Hobbs",
+			Link:         "https://www.takealot.com/russell-hobbs-glass-kettle/PLID1002",
+			Images:       []string{"https://media.takealot.com/covers_images/b1/s-zoom.file"},
+			Availability: model.OutOfStock,
+		},
+		{
+			ID:           "1003",
+			Title:        "Smeg Retro Kettle",
+			Brand:        "Smeg",
+			Link:         "https://www.takealot.com/smeg-retro-kettle/PLID1003",
+			Images:       []string{},
+			Price:        1299,
+			Availability: model.PreOrder,
+		},
+		{
+			ID:           "1004",
+			Title:        "Bosch Variable Temperature Kettle",
+			Brand:        "Bosch",
+			Link:         "https://www.takealot.com/bosch-variable-temperature-kettle/PLID1004",
+			Images:       []string{"https://media.takealot.com/covers_images/d1/s-zoom.file"},
+			Price:        1499,
+			Availability: model.LeadTime,
+			LeadTime:     "Ships in 5 - 7 work days",
+		},
+	}
+	if !reflect.DeepEqual(page.Listings, want) {
+		t.Errorf("listings:\n got %+v\nwant %+v", page.Listings, want)
+	}
+	if page.Next != "WzEwXQ==" {
+		t.Errorf("Next = %q, want %q", page.Next, "WzEwXQ==")
+	}
+
+	wantSkipped := []struct {
+		id     string
+		reason scraper.SkipReason
+	}{
+		{"", SkipMalformed},
+		{"", SkipNoViews},
+		{"2001", SkipMissingBlock},
+		{"2002", SkipNoImages},
+		{"2003", SkipNoTitle},
+		{"2004", SkipNoBrand},
+		{"2005", SkipNoSlug},
+		{"", SkipNoProductID},
+		{"2007", SkipUnusablePrice},
+	}
+	if len(page.Skipped) != len(wantSkipped) {
+		t.Fatalf("skipped %d results, want %d: %+v", len(page.Skipped), len(wantSkipped), page.Skipped)
+	}
+	for i, w := range wantSkipped {
+		got := page.Skipped[i]
+		if got.ID != w.id || got.Reason != w.reason {
+			t.Errorf("skipped[%d] = %q %q, want %q %q", i, got.ID, got.Reason, w.id, w.reason)
+		}
+		if got.Sample == "" {
+			t.Errorf("skipped[%d] has no sample", i)
+		}
+	}
+}
+
+func TestParseSchemaError(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		reason string
+	}{
+		{"not json", `<html>blocked</html>`, "invalid character"},
+		{"top level array", `[]`, "unexpected shape"},
+		{"sections not an object", `{"sections": []}`, "sections: unexpected shape"},
+		{"results missing", `{"sections": {"products": {"paging": {}}}}`, "sections.products.results missing"},
+		{"products missing", `{"sections": {"filters": {}}}`, "sections.products.results missing"},
+		{"results not an array", `{"sections": {"products": {"results": {}}}}`, "results: unexpected shape"},
+		{"paging wrong type", `{"sections": {"products": {"paging": {"next_is_after": 3}, "results": []}}}`, "cannot unmarshal number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.body))
+			var schemaErr *scraper.SchemaError
+			if !errors.As(err, &schemaErr) {
+				t.Fatalf("Parse error = %v, want *scraper.SchemaError", err)
+			}
+			if !strings.Contains(schemaErr.Reason, tt.reason) {
+				t.Errorf("Reason = %q, want it to contain %q", schemaErr.Reason, tt.reason)
+			}
+		})
+	}
+}
+
+func TestParseRawSample(t *testing.T) {
+	s := New(Defaults(), nil)
+	_, err := s.ParseRaw(context.Background(), []byte(`{"sections": {}}`))
+	var schemaErr *scraper.SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("ParseRaw error = %v, want *scraper.SchemaError", err)
+	}
+	if schemaErr.Sample != `{"sections": {}}` {
+		t.Errorf("Sample = %q", schemaErr.Sample)
+	}
+}
+
+func TestSearchReplay(t *testing.T) {
+	s := New(Defaults(), httpx.Replay("testdata/replay"))
+
+	page, err := s.Search(context.Background(), "kettle", "WzEwXQ==")
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	want := []scraper.Listing{{
+		ID:           "1011",
+		Title:        "Kenwood Dome Kettle",
+		Brand:        "Kenwood",
+		Link:         "https://www.takealot.com/kenwood-dome-kettle/PLID1011",
+		Images:       []string{"https://media.takealot.com/covers_images/k1/s-zoom.file"},
+		Price:        549,
+		ListPrice:    549,
+		Availability: model.LimitedStock,
+	}}
+	if !reflect.DeepEqual(page.Listings, want) {
+		t.Errorf("listings:\n got %+v\nwant %+v", page.Listings, want)
+	}
+	if len(page.Skipped) != 0 || page.Next != "" {
+		t.Errorf("skipped = %v, next = %q; want none", page.Skipped, page.Next)
+	}
+
+	if _, err := s.Search(context.Background(), "toaster", ""); !errors.Is(err, httpx.ErrNoFixture) {
+		t.Errorf("unrecorded search error = %v, want ErrNoFixture", err)
+	}
+}
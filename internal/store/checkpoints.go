@@ -0,0 +1,184 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/metrics"
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Checkpointer persists crawl progress so an interrupted pass can resume.
+type Checkpointer interface {
+	Checkpoint(ctx context.Context, source, keyword string) (model.Checkpoint, error)
+	SaveCheckpoint(ctx context.Context, cp model.Checkpoint) error
+	ListCheckpoints(ctx context.Context, source string) ([]model.Checkpoint, error)
+	// DeleteCheckpoints removes the named keywords' checkpoints, or every
+	// checkpoint for source when no keywords are given.
+	DeleteCheckpoints(ctx context.Context, source string, keywords ...string) (int64, error)
+}
+
+type mongoCheckpoint struct {
+	ID      string    `bson:"_id"`
+	Source  string    `bson:"source"`
+	Keyword string    `bson:"keyword"`
+	RunID   string    `bson:"run_id"`
+	Cursor  string    `bson:"cursor"`
+	Page    int       `bson:"page"`
+	Done    bool      `bson:"done"`
+	Updated time.Time `bson:"updated"`
+}
+
+func (d mongoCheckpoint) model() model.Checkpoint {
+	return model.Checkpoint{
+		Source:  d.Source,
+		Keyword: d.Keyword,
+		RunID:   d.RunID,
+		Cursor:  d.Cursor,
+		Page:    d.Page,
+		Done:    d.Done,
+		Updated: d.Updated,
+	}
+}
+
+func (m *Mongo) Checkpoint(parentCtx context.Context, source, keyword string) (model.Checkpoint, error) {
+	defer metrics.ObserveDB(KindMongo, "checkpoint")()
+	ctx, cancel := context.WithTimeout(parentCtx, m.opTimeout)
+	defer cancel()
+
+	var doc mongoCheckpoint
+	if err := m.checkpointsColl.FindOne(ctx, bson.M{"_id": keywordKey(source, keyword)}).Decode(&doc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return model.Checkpoint{}, ErrNotFound
+		}
+		return model.Checkpoint{}, fmt.Errorf("find checkpoint: %w", err)
+	}
+	return doc.model(), nil
+}
+
+func (m *Mongo) SaveCheckpoint(parentCtx context.Context, cp model.Checkpoint) error {
+	defer metrics.ObserveDB(KindMongo, "save_checkpoint")()
+	ctx, cancel := context.WithTimeout(parentCtx, m.opTimeout)
+	defer cancel()
+
+	if cp.Updated.IsZero() {
+		cp.Updated = time.Now().UTC()
+	}
+	doc := mongoCheckpoint{
+		ID:      keywordKey(cp.Source, cp.Keyword),
+		Source:  cp.Source,
+		Keyword: cp.Keyword,
+		RunID:   cp.RunID,
+		Cursor:  cp.Cursor,
+		Page:    cp.Page,
+		Done:    cp.Done,
+		Updated: cp.Updated,
+	}
+	opts := options.Replace().SetUpsert(true)
+	if _, err := m.checkpointsColl.ReplaceOne(ctx, bson.M{"_id": doc.ID}, doc, opts); err != nil {
+		return fmt.Errorf("save checkpoint: %w", err)
+	}
+	return nil
+}
+
+func (m *Mongo) ListCheckpoints(ctx context.Context, source string) ([]model.Checkpoint, error) {
+	defer metrics.ObserveDB(KindMongo, "list_checkpoints")()
+	opts := options.Find().SetSort(bson.D{{Key: "keyword", Value: 1}})
+	cursor, err := m.checkpointsColl.Find(ctx, bson.M{"source": source}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("find checkpoints: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var checkpoints []model.Checkpoint
+	for cursor.Next(ctx) {
+		var doc mongoCheckpoint
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("decode checkpoint: %w", err)
+		}
+		checkpoints = append(checkpoints, doc.model())
+	}
+	return checkpoints, cursor.Err()
+}
+
+func (m *Mongo) DeleteCheckpoints(parentCtx context.Context, source string, keywords ...string) (int64, error) {
+	defer metrics.ObserveDB(KindMongo, "delete_checkpoints")()
+	ctx, cancel := context.WithTimeout(parentCtx, m.opTimeout)
+	defer cancel()
+
+	filter := bson.M{"source": source}
+	if len(keywords) > 0 {
+		filter["keyword"] = bson.M{"$in": keywords}
+	}
+	res, err := m.checkpointsColl.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("delete checkpoints: %w", err)
+	}
+	return res.DeletedCount, nil
+}
+
+func (m *Memory) Checkpoint(_ context.Context, source, keyword string) (model.Checkpoint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cp, ok := m.checkpoints[keywordKey(source, keyword)]
+	if !ok {
+		return model.Checkpoint{}, ErrNotFound
+	}
+	return cp, nil
+}
+
+func (m *Memory) SaveCheckpoint(_ context.Context, cp model.Checkpoint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if cp.Updated.IsZero() {
+		cp.Updated = time.Now().UTC()
+	}
+	m.checkpoints[keywordKey(cp.Source, cp.Keyword)] = cp
+	return nil
+}
+
+func (m *Memory) ListCheckpoints(_ context.Context, source string) ([]model.Checkpoint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var checkpoints []model.Checkpoint
+	for _, cp := range m.checkpoints {
+		if cp.Source == source {
+			checkpoints = append(checkpoints, cp)
+		}
+	}
+	sort.Slice(checkpoints, func(i, j int) bool { return checkpoints[i].Keyword < checkpoints[j].Keyword })
+	return checkpoints, nil
+}
+
+func (m *Memory) DeleteCheckpoints(_ context.Context, source string, keywords ...string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var n int64
+	if len(keywords) == 0 {
+		for key, cp := range m.checkpoints {
+			if cp.Source == source {
+				delete(m.checkpoints, key)
+				n++
+			}
+		}
+		return n, nil
+	}
+	for _, keyword := range keywords {
+		key := keywordKey(source, keyword)
+		if _, ok := m.checkpoints[key]; ok {
+			delete(m.checkpoints, key)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,120 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/metrics"
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Detailer keeps the product details fetched from retailers' product
+// pages, one record per item.
+type Detailer interface {
+	// SaveDetails replaces the details of d.ItemID.
+	SaveDetails(ctx context.Context, d model.Details) error
+	Details(ctx context.Context, itemID string) (model.Details, error)
+	// DetailsFetched returns when the details of each of source's items
+	// were last fetched, by item ID.
+	DetailsFetched(ctx context.Context, source string) (map[string]time.Time, error)
+}
+
+type mongoDetails struct {
+	ItemID      string            `bson:"_id"`
+	Source      string            `bson:"source"`
+	SourceID    string            `bson:"source_id"`
+	Description string            `bson:"description,omitempty"`
+	Specs       map[string]string `bson:"specs,omitempty"`
+	Categories  []string          `bson:"categories,omitempty"`
+	Barcodes    []string          `bson:"barcodes,omitempty"`
+	Warranty    string            `bson:"warranty,omitempty"`
+	Rating      float64           `bson:"rating"`
+	Reviews     int               `bson:"reviews"`
+	Fetched     time.Time         `bson:"fetched"`
+}
+
+func (m *Mongo) SaveDetails(parentCtx context.Context, d model.Details) error {
+	defer metrics.ObserveDB(KindMongo, "save_details")()
+	ctx, cancel := context.WithTimeout(parentCtx, m.opTimeout)
+	defer cancel()
+
+	doc := mongoDetails(d)
+	_, err := m.detailsColl.ReplaceOne(ctx, bson.M{"_id": d.ItemID}, doc, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("save details: %w", err)
+	}
+	return nil
+}
+
+func (m *Mongo) Details(parentCtx context.Context, itemID string) (model.Details, error) {
+	defer metrics.ObserveDB(KindMongo, "details")()
+	ctx, cancel := context.WithTimeout(parentCtx, m.opTimeout)
+	defer cancel()
+
+	var doc mongoDetails
+	err := m.detailsColl.FindOne(ctx, bson.M{"_id": itemID}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return model.Details{}, ErrNotFound
+	}
+	if err != nil {
+		return model.Details{}, fmt.Errorf("find details: %w", err)
+	}
+	return model.Details(doc), nil
+}
+
+func (m *Mongo) DetailsFetched(ctx context.Context, source string) (map[string]time.Time, error) {
+	defer metrics.ObserveDB(KindMongo, "details_fetched")()
+	opts := options.Find().SetProjection(bson.M{"fetched": 1})
+	cursor, err := m.detailsColl.Find(ctx, bson.M{"source": source}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("find details: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	fetched := make(map[string]time.Time)
+	for cursor.Next(ctx) {
+		var doc struct {
+			ItemID  string    `bson:"_id"`
+			Fetched time.Time `bson:"fetched"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("decode details: %w", err)
+		}
+		fetched[doc.ItemID] = doc.Fetched
+	}
+	return fetched, cursor.Err()
+}
+
+func (m *Memory) SaveDetails(_ context.Context, d model.Details) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.details[d.ItemID] = d
+	return nil
+}
+
+func (m *Memory) Details(_ context.Context, itemID string) (model.Details, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d, ok := m.details[itemID]
+	if !ok {
+		return model.Details{}, ErrNotFound
+	}
+	return d, nil
+}
+
+func (m *Memory) DetailsFetched(_ context.Context, source string) (map[string]time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fetched := make(map[string]time.Time)
+	for id, d := range m.details {
+		if d.Source == source {
+			fetched[id] = d.Fetched
+		}
+	}
+	return fetched, nil
+}
@@ -0,0 +1,243 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/metrics"
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Catalogue keeps the keywords the scrapers search for.
+type Catalogue interface {
+	// ListKeywords returns every keyword, sorted by category and keyword.
+	ListKeywords(ctx context.Context) ([]model.Keyword, error)
+	// AddKeywords inserts the keywords not already in the catalogue,
+	// matching case-insensitively, and reports how many were new.
+	AddKeywords(ctx context.Context, keywords ...model.Keyword) (int, error)
+	SetKeywordEnabled(ctx context.Context, keyword string, enabled bool) error
+	// RecordKeywordCrawl adds yield's counts to keyword's stats for source
+	// and sets its LastCrawled.
+	RecordKeywordCrawl(ctx context.Context, keyword, source string, yield model.KeywordStats) error
+}
+
+func catalogueKey(keyword string) string {
+	return strings.ToLower(strings.TrimSpace(keyword))
+}
+
+type mongoKeywordStats struct {
+	LastCrawled time.Time `bson:"last_crawled"`
+	Crawls      int64     `bson:"crawls"`
+	Pages       int64     `bson:"pages"`
+	Listings    int64     `bson:"listings"`
+	NewItems    int64     `bson:"new_items"`
+}
+
+type mongoKeyword struct {
+	ID       string                       `bson:"_id"`
+	Keyword  string                       `bson:"keyword"`
+	Category string                       `bson:"category"`
+	Enabled  bool                         `bson:"enabled"`
+	Sources  []string                     `bson:"sources,omitempty"`
+	AddedBy  string                       `bson:"added_by"`
+	Added    time.Time                    `bson:"added"`
+	Stats    map[string]mongoKeywordStats `bson:"stats,omitempty"`
+}
+
+func (d mongoKeyword) model() model.Keyword {
+	k := model.Keyword{
+		Keyword:  d.Keyword,
+		Category: d.Category,
+		Enabled:  d.Enabled,
+		Sources:  d.Sources,
+		AddedBy:  d.AddedBy,
+		Added:    d.Added,
+	}
+	if len(d.Stats) > 0 {
+		k.Stats = make(map[string]model.KeywordStats, len(d.Stats))
+		for source, s := range d.Stats {
+			k.Stats[source] = model.KeywordStats(s)
+		}
+	}
+	return k
+}
+
+func sortKeywords(keywords []model.Keyword) {
+	sort.Slice(keywords, func(i, j int) bool {
+		if keywords[i].Category != keywords[j].Category {
+			return keywords[i].Category < keywords[j].Category
+		}
+		return catalogueKey(keywords[i].Keyword) < catalogueKey(keywords[j].Keyword)
+	})
+}
+
+func (m *Mongo) ListKeywords(ctx context.Context) ([]model.Keyword, error) {
+	defer metrics.ObserveDB(KindMongo, "list_keywords")()
+	cursor, err := m.keywordsColl.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("find keywords: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var keywords []model.Keyword
+	for cursor.Next(ctx) {
+		var doc mongoKeyword
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("decode keyword: %w", err)
+		}
+		keywords = append(keywords, doc.model())
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	sortKeywords(keywords)
+	return keywords, nil
+}
+
+func (m *Mongo) AddKeywords(parentCtx context.Context, keywords ...model.Keyword) (int, error) {
+	defer metrics.ObserveDB(KindMongo, "add_keywords")()
+	ctx, cancel := context.WithTimeout(parentCtx, m.opTimeout)
+	defer cancel()
+
+	now := time.Now().UTC()
+	added := 0
+	for _, k := range keywords {
+		if k.Added.IsZero() {
+			k.Added = now
+		}
+		doc := mongoKeyword{
+			ID:       catalogueKey(k.Keyword),
+			Keyword:  strings.TrimSpace(k.Keyword),
+			Category: k.Category,
+			Enabled:  k.Enabled,
+			Sources:  k.Sources,
+			AddedBy:  k.AddedBy,
+			Added:    k.Added,
+		}
+		opts := options.Update().SetUpsert(true)
+		res, err := m.keywordsColl.UpdateOne(ctx, bson.M{"_id": doc.ID}, bson.M{"$setOnInsert": doc}, opts)
+		if err != nil {
+			return added, fmt.Errorf("add keyword %q: %w", k.Keyword, err)
+		}
+		added += int(res.UpsertedCount)
+	}
+	return added, nil
+}
+
+func (m *Mongo) SetKeywordEnabled(parentCtx context.Context, keyword string, enabled bool) error {
+	defer metrics.ObserveDB(KindMongo, "set_keyword_enabled")()
+	ctx, cancel := context.WithTimeout(parentCtx, m.opTimeout)
+	defer cancel()
+
+	res, err := m.keywordsColl.UpdateOne(ctx, bson.M{"_id": catalogueKey(keyword)}, bson.M{"$set": bson.M{"enabled": enabled}})
+	if err != nil {
+		return fmt.Errorf("update keyword: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m *Mongo) RecordKeywordCrawl(parentCtx context.Context, keyword, source string, yield model.KeywordStats) error {
+	defer metrics.ObserveDB(KindMongo, "record_keyword_crawl")()
+	ctx, cancel := context.WithTimeout(parentCtx, m.opTimeout)
+	defer cancel()
+
+	prefix := "stats." + source + "."
+	update := bson.M{
+		"$set": bson.M{prefix + "last_crawled": yield.LastCrawled},
+		"$inc": bson.M{
+			prefix + "crawls":    yield.Crawls,
+			prefix + "pages":     yield.Pages,
+			prefix + "listings":  yield.Listings,
+			prefix + "new_items": yield.NewItems,
+		},
+	}
+	res, err := m.keywordsColl.UpdateOne(ctx, bson.M{"_id": catalogueKey(keyword)}, update)
+	if err != nil {
+		return fmt.Errorf("record keyword crawl: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m *Memory) ListKeywords(context.Context) ([]model.Keyword, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keywords := make([]model.Keyword, 0, len(m.keywords))
+	for _, k := range m.keywords {
+		keywords = append(keywords, k)
+	}
+	sortKeywords(keywords)
+	return keywords, nil
+}
+
+func (m *Memory) AddKeywords(_ context.Context, keywords ...model.Keyword) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UTC()
+	added := 0
+	for _, k := range keywords {
+		key := catalogueKey(k.Keyword)
+		if _, ok := m.keywords[key]; ok {
+			continue
+		}
+		k.Keyword = strings.TrimSpace(k.Keyword)
+		if k.Added.IsZero() {
+			k.Added = now
+		}
+		k.Stats = nil
+		m.keywords[key] = k
+		added++
+	}
+	return added, nil
+}
+
+func (m *Memory) SetKeywordEnabled(_ context.Context, keyword string, enabled bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := catalogueKey(keyword)
+	k, ok := m.keywords[key]
+	if !ok {
+		return ErrNotFound
+	}
+	k.Enabled = enabled
+	m.keywords[key] = k
+	return nil
+}
+
+func (m *Memory) RecordKeywordCrawl(_ context.Context, keyword, source string, yield model.KeywordStats) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := catalogueKey(keyword)
+	k, ok := m.keywords[key]
+	if !ok {
+		return ErrNotFound
+	}
+	stats := make(map[string]model.KeywordStats, len(k.Stats)+1)
+	for s, v := range k.Stats {
+		stats[s] = v
+	}
+	s := stats[source]
+	s.LastCrawled = yield.LastCrawled
+	s.Crawls += yield.Crawls
+	s.Pages += yield.Pages
+	s.Listings += yield.Listings
+	s.NewItems += yield.NewItems
+	stats[source] = s
+	k.Stats = stats
+	m.keywords[key] = k
+	return nil
+}
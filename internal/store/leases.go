@@ -0,0 +1,192 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/metrics"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LeaseState int
+
+const (
+	// LeaseClaimed means the caller now owns the keyword.
+	LeaseClaimed LeaseState = iota
+	// LeaseHeld means another live worker owns the keyword.
+	LeaseHeld
+	// LeaseDone means the keyword was finished within the cooldown.
+	LeaseDone
+)
+
+var ErrLeaseLost = errors.New("lease lost")
+
+// Leaser coordinates several scraper processes working through the same
+// keyword list. A lease is held for ttl and must be renewed before it
+// expires; once released as finished the keyword cannot be claimed again
+// until cooldown has passed.
+type Leaser interface {
+	ClaimLease(ctx context.Context, source, keyword, owner string, ttl, cooldown time.Duration) (LeaseState, error)
+	RenewLease(ctx context.Context, source, keyword, owner string, ttl time.Duration) error
+	ReleaseLease(ctx context.Context, source, keyword, owner string, finished bool) error
+}
+
+// keywordKey is the document ID for per-source, per-keyword state.
+func keywordKey(source, keyword string) string {
+	return source + ":" + keyword
+}
+
+type mongoLease struct {
+	ID         string    `bson:"_id"`
+	Source     string    `bson:"source"`
+	Keyword    string    `bson:"keyword"`
+	Owner      string    `bson:"owner"`
+	ClaimedAt  time.Time `bson:"claimed_at"`
+	ExpiresAt  time.Time `bson:"expires_at"`
+	FinishedAt time.Time `bson:"finished_at,omitempty"`
+}
+
+func (m *Mongo) ClaimLease(parentCtx context.Context, source, keyword, owner string, ttl, cooldown time.Duration) (LeaseState, error) {
+	defer metrics.ObserveDB(KindMongo, "claim_lease")()
+	ctx, cancel := context.WithTimeout(parentCtx, m.opTimeout)
+	defer cancel()
+
+	now := time.Now().UTC()
+	key := keywordKey(source, keyword)
+	filter := bson.M{
+		"_id": key,
+		"$and": bson.A{
+			bson.M{"$or": bson.A{
+				bson.M{"owner": owner},
+				bson.M{"expires_at": bson.M{"$lt": now}},
+			}},
+			bson.M{"$or": bson.A{
+				bson.M{"finished_at": bson.M{"$exists": false}},
+				bson.M{"finished_at": bson.M{"$lt": now.Add(-cooldown)}},
+			}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"source":     source,
+			"keyword":    keyword,
+			"owner":      owner,
+			"claimed_at": now,
+			"expires_at": now.Add(ttl),
+		},
+	}
+
+	_, err := m.leasesColl.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err == nil {
+		return LeaseClaimed, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return LeaseHeld, fmt.Errorf("claim lease %s: %w", key, err)
+	}
+
+	// The lease exists and did not match; work out why.
+	var doc mongoLease
+	if err := m.leasesColl.FindOne(ctx, bson.M{"_id": key}).Decode(&doc); err != nil {
+		return LeaseHeld, fmt.Errorf("read lease %s: %w", key, err)
+	}
+	if !doc.FinishedAt.IsZero() && !doc.FinishedAt.Before(now.Add(-cooldown)) {
+		return LeaseDone, nil
+	}
+	return LeaseHeld, nil
+}
+
+func (m *Mongo) RenewLease(parentCtx context.Context, source, keyword, owner string, ttl time.Duration) error {
+	defer metrics.ObserveDB(KindMongo, "renew_lease")()
+	ctx, cancel := context.WithTimeout(parentCtx, m.opTimeout)
+	defer cancel()
+
+	filter := bson.M{"_id": keywordKey(source, keyword), "owner": owner}
+	res, err := m.leasesColl.UpdateOne(ctx, filter, bson.M{
+		"$set": bson.M{"expires_at": time.Now().UTC().Add(ttl)},
+	})
+	if err != nil {
+		return fmt.Errorf("renew lease: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+func (m *Mongo) ReleaseLease(parentCtx context.Context, source, keyword, owner string, finished bool) error {
+	defer metrics.ObserveDB(KindMongo, "release_lease")()
+	ctx, cancel := context.WithTimeout(parentCtx, m.opTimeout)
+	defer cancel()
+
+	now := time.Now().UTC()
+	set := bson.M{"expires_at": now}
+	if finished {
+		set["finished_at"] = now
+	}
+	filter := bson.M{"_id": keywordKey(source, keyword), "owner": owner}
+	if _, err := m.leasesColl.UpdateOne(ctx, filter, bson.M{"$set": set}); err != nil {
+		return fmt.Errorf("release lease: %w", err)
+	}
+	return nil
+}
+
+type memoryLease struct {
+	owner      string
+	expiresAt  time.Time
+	finishedAt time.Time
+}
+
+func (m *Memory) ClaimLease(_ context.Context, source, keyword, owner string, ttl, cooldown time.Duration) (LeaseState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UTC()
+	key := keywordKey(source, keyword)
+	lease := m.leases[key]
+	if !lease.finishedAt.IsZero() && !lease.finishedAt.Before(now.Add(-cooldown)) {
+		return LeaseDone, nil
+	}
+	if lease.owner != "" && lease.owner != owner && lease.expiresAt.After(now) {
+		return LeaseHeld, nil
+	}
+	lease.owner = owner
+	lease.expiresAt = now.Add(ttl)
+	m.leases[key] = lease
+	return LeaseClaimed, nil
+}
+
+func (m *Memory) RenewLease(_ context.Context, source, keyword, owner string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := keywordKey(source, keyword)
+	lease, ok := m.leases[key]
+	if !ok || lease.owner != owner {
+		return ErrLeaseLost
+	}
+	lease.expiresAt = time.Now().UTC().Add(ttl)
+	m.leases[key] = lease
+	return nil
+}
+
+func (m *Memory) ReleaseLease(_ context.Context, source, keyword, owner string, finished bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := keywordKey(source, keyword)
+	lease, ok := m.leases[key]
+	if !ok || lease.owner != owner {
+		return nil
+	}
+	now := time.Now().UTC()
+	lease.expiresAt = now
+	if finished {
+		lease.finishedAt = now
+	}
+	m.leases[key] = lease
+	return nil
+}
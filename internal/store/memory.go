@@ -0,0 +1,239 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+)
+
+// Memory is a process-local Store for dry runs and parser development.
+type Memory struct {
+	mu          sync.Mutex
+	items       map[string]model.Item
+	bySrc       map[string]string
+	prices      map[string][]model.Price
+	watches     []model.Watch
+	leases      map[string]memoryLease
+	checkpoints map[string]model.Checkpoint
+	quarantine  []model.Quarantined
+	runs        map[string]model.Run
+	schedules   map[string]model.Schedule
+	recrawls    map[string]model.Recrawl
+	keywords    map[string]model.Keyword
+	details     map[string]model.Details
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		items:       map[string]model.Item{},
+		bySrc:       map[string]string{},
+		prices:      map[string][]model.Price{},
+		leases:      map[string]memoryLease{},
+		checkpoints: map[string]model.Checkpoint{},
+		runs:        map[string]model.Run{},
+		schedules:   map[string]model.Schedule{},
+		recrawls:    map[string]model.Recrawl{},
+		keywords:    map[string]model.Keyword{},
+		details:     map[string]model.Details{},
+	}
+}
+
+func sourceKey(source, sourceID string) string {
+	return source + "\x00" + sourceID
+}
+
+func (m *Memory) UpsertItem(_ context.Context, item *model.Item) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UTC()
+	if item.Updated.IsZero() {
+		item.Updated = now
+	}
+	if item.ID == "" {
+		item.ID = m.bySrc[sourceKey(item.Source, item.SourceID)]
+	}
+
+	existing, ok := m.items[item.ID]
+	if ok && !existing.Updated.Before(item.Updated) {
+		// A replayed capture does not revert a later one.
+		*item = existing
+		item.Images = append([]string(nil), existing.Images...)
+		return false, nil
+	}
+	if ok {
+		item.Created = existing.Created
+		if item.Availability == model.AvailabilityUnknown {
+			item.Availability = existing.Availability
+		}
+	} else {
+		if item.ID == "" {
+			item.ID = uuid.NewString()
+		}
+		if item.Created.IsZero() {
+			item.Created = now
+		}
+	}
+
+	stored := *item
+	stored.Images = append([]string(nil), item.Images...)
+	m.items[item.ID] = stored
+	m.bySrc[sourceKey(item.Source, item.SourceID)] = item.ID
+	return !ok, nil
+}
+
+func (m *Memory) Item(_ context.Context, id string) (model.Item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, ok := m.items[id]
+	if !ok {
+		return model.Item{}, ErrNotFound
+	}
+	return item, nil
+}
+
+func (m *Memory) EachItem(_ context.Context, fn func(model.Item) error) error {
+	m.mu.Lock()
+	items := make([]model.Item, 0, len(m.items))
+	for _, item := range m.items {
+		items = append(items, item)
+	}
+	m.mu.Unlock()
+
+	for _, item := range items {
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Memory) Brands(context.Context) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	seen := map[string]struct{}{}
+	var brands []string
+	for _, item := range m.items {
+		if item.Brand == "" || item.Brand == "." {
+			continue
+		}
+		if _, ok := seen[item.Brand]; !ok {
+			seen[item.Brand] = struct{}{}
+			brands = append(brands, item.Brand)
+		}
+	}
+	return brands, nil
+}
+
+func (m *Memory) AppendPrice(_ context.Context, price model.Price) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	history := append(m.prices[price.ItemID], price)
+	sort.SliceStable(history, func(i, j int) bool { return history[i].Date.Before(history[j].Date) })
+	m.prices[price.ItemID] = history
+	return nil
+}
+
+func (m *Memory) TouchPrice(_ context.Context, price model.Price, seen time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	history := m.prices[price.ItemID]
+	for i := range history {
+		if history[i].Date.Equal(price.Date) && seen.After(history[i].LastSeen) {
+			history[i].LastSeen = seen
+		}
+	}
+	return nil
+}
+
+func (m *Memory) LatestPrice(_ context.Context, itemID string, at time.Time) (model.Price, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	history := m.prices[itemID]
+	for i := len(history) - 1; i >= 0; i-- {
+		if !history[i].Date.After(at) {
+			return history[i], nil
+		}
+	}
+	return model.Price{}, ErrNotFound
+}
+
+func (m *Memory) PriceHistory(_ context.Context, itemID string) ([]model.Price, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]model.Price(nil), m.prices[itemID]...), nil
+}
+
+func (m *Memory) EachPrice(ctx context.Context, fn func(model.Price) error) error {
+	return m.EachPriceSince(ctx, time.Time{}, fn)
+}
+
+func (m *Memory) EachPriceSince(_ context.Context, since time.Time, fn func(model.Price) error) error {
+	m.mu.Lock()
+	var prices []model.Price
+	for _, history := range m.prices {
+		for _, price := range history {
+			if !price.Date.Before(since) || !price.LastSeen.Before(since) {
+				prices = append(prices, price)
+			}
+		}
+	}
+	m.mu.Unlock()
+
+	for _, price := range prices {
+		if err := fn(price); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddWatch registers a watch; the other backends get watches from the app.
+func (m *Memory) AddWatch(watch model.Watch) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.watches = append(m.watches, watch)
+}
+
+func (m *Memory) ListWatches(context.Context) ([]model.Watch, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]model.Watch(nil), m.watches...), nil
+}
+
+func (m *Memory) Stats(context.Context) (model.Stats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := model.Stats{ItemsBySource: map[string]int64{}}
+	for _, item := range m.items {
+		stats.ItemsBySource[item.Source]++
+	}
+	for _, history := range m.prices {
+		stats.Prices += int64(len(history))
+		if n := len(history); n > 0 && history[n-1].Date.After(stats.LatestPrice) {
+			stats.LatestPrice = history[n-1].Date
+		}
+	}
+	return stats, nil
+}
+
+func (m *Memory) Ping(context.Context) error {
+	return nil
+}
+
+func (m *Memory) Close(context.Context) error {
+	return nil
+}
@@ -0,0 +1,128 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+)
+
+func TestMemoryUpsertItem(t *testing.T) {
+	day := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	first := model.Item{
+		Source:       "takealot",
+		SourceID:     "123",
+		Title:        "Kettle",
+		Availability: model.InStock,
+		Created:      day,
+		Updated:      day,
+	}
+
+	tests := []struct {
+		name         string
+		next         model.Item
+		title        string
+		availability model.Availability
+		updated      time.Time
+	}{
+		{
+			name:         "newer sighting overwrites",
+			next:         model.Item{Title: "Kettle 1.7l", Availability: model.OutOfStock, Updated: day.Add(time.Hour)},
+			title:        "Kettle 1.7l",
+			availability: model.OutOfStock,
+			updated:      day.Add(time.Hour),
+		},
+		{
+			name:         "unknown availability keeps the stored one",
+			next:         model.Item{Title: "Kettle 1.7l", Availability: model.AvailabilityUnknown, Updated: day.Add(time.Hour)},
+			title:        "Kettle 1.7l",
+			availability: model.InStock,
+			updated:      day.Add(time.Hour),
+		},
+		{
+			name:         "older capture leaves the item alone",
+			next:         model.Item{Title: "Old kettle", Availability: model.OutOfStock, Updated: day.Add(-24 * time.Hour)},
+			title:        "Kettle",
+			availability: model.InStock,
+			updated:      day,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			st := NewMemory()
+			item := first
+			created, err := st.UpsertItem(ctx, &item)
+			if err != nil || !created {
+				t.Fatalf("first UpsertItem = %v, %v; want created", created, err)
+			}
+
+			next := tt.next
+			next.Source, next.SourceID = first.Source, first.SourceID
+			created, err = st.UpsertItem(ctx, &next)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if created {
+				t.Error("second UpsertItem created a new item")
+			}
+			if next.ID != item.ID {
+				t.Errorf("ID = %q, want %q", next.ID, item.ID)
+			}
+			if next.Availability != tt.availability {
+				t.Errorf("returned availability = %q, want %q", next.Availability, tt.availability)
+			}
+
+			stored, err := st.Item(ctx, item.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.Title != tt.title {
+				t.Errorf("Title = %q, want %q", stored.Title, tt.title)
+			}
+			if stored.Availability != tt.availability {
+				t.Errorf("Availability = %q, want %q", stored.Availability, tt.availability)
+			}
+			if !stored.Updated.Equal(tt.updated) {
+				t.Errorf("Updated = %v, want %v", stored.Updated, tt.updated)
+			}
+			if !stored.Created.Equal(item.Created) {
+				t.Errorf("Created = %v, want the first sighting's %v", stored.Created, item.Created)
+			}
+		})
+	}
+}
+
+func TestMemoryEachPriceSince(t *testing.T) {
+	ctx := context.Background()
+	since := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	st := NewMemory()
+	points := map[string]model.Price{
+		"old":         {ItemID: "a", Date: since.Add(-48 * time.Hour), LastSeen: since.Add(-24 * time.Hour)},
+		"seen since":  {ItemID: "a", Date: since.Add(-24 * time.Hour), LastSeen: since},
+		"dated since": {ItemID: "b", Date: since, LastSeen: since},
+		"never seen":  {ItemID: "b", Date: since.Add(time.Hour)},
+	}
+	for _, p := range points {
+		if err := st.AppendPrice(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := 0
+	err := st.EachPriceSince(ctx, since, func(p model.Price) error {
+		if p.Date.Equal(points["old"].Date) {
+			t.Errorf("point from before %v read: %+v", since, p)
+		}
+		got++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 3 {
+		t.Errorf("read %d points, want 3", got)
+	}
+}
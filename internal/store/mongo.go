@@ -0,0 +1,532 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/database"
+	"github.com/mindsgn-studio/takealot-scraper/internal/metrics"
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoSource struct {
+	ID     string `bson:"id"`
+	Source string `bson:"source"`
+}
+
+type mongoItem struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"`
+	Title        string             `bson:"title"`
+	Images       []string           `bson:"images"`
+	Link         string             `bson:"link"`
+	Brand        string             `bson:"brand"`
+	Sources      mongoSource        `bson:"sources"`
+	Availability string             `bson:"availability,omitempty"`
+	Created      time.Time          `bson:"created"`
+	Updated      time.Time          `bson:"updated"`
+}
+
+type mongoPrice struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"`
+	ItemID       primitive.ObjectID `bson:"itemID"`
+	Date         time.Time          `bson:"date"`
+	LastSeen     time.Time          `bson:"last_seen,omitempty"`
+	Currency     string             `bson:"currency"`
+	Price        float64            `bson:"price"`
+	ListPrice    float64            `bson:"list_price,omitempty"`
+	Savings      float64            `bson:"savings,omitempty"`
+	DiscountPct  float64            `bson:"discount_pct,omitempty"`
+	Availability string             `bson:"availability,omitempty"`
+	LeadTime     string             `bson:"lead_time,omitempty"`
+}
+
+type mongoWatch struct {
+	ItemID  string    `bson:"itemID"`
+	User    string    `bson:"user"`
+	Token   string    `bson:"token"`
+	Device  string    `bson:"device"`
+	Created time.Time `bson:"created"`
+}
+
+func (d mongoItem) model() model.Item {
+	return model.Item{
+		ID:           d.ID.Hex(),
+		Source:       d.Sources.Source,
+		SourceID:     d.Sources.ID,
+		Title:        d.Title,
+		Images:       d.Images,
+		Link:         d.Link,
+		Brand:        d.Brand,
+		Availability: model.Availability(d.Availability),
+		Created:      d.Created,
+		Updated:      d.Updated,
+	}
+}
+
+func (d mongoPrice) model() model.Price {
+	return model.Price{
+		ItemID:       d.ItemID.Hex(),
+		Date:         d.Date,
+		LastSeen:     d.LastSeen,
+		Currency:     d.Currency,
+		Price:        d.Price,
+		ListPrice:    d.ListPrice,
+		Savings:      d.Savings,
+		DiscountPct:  d.DiscountPct,
+		Availability: model.Availability(d.Availability),
+		LeadTime:     d.LeadTime,
+	}
+}
+
+type Mongo struct {
+	client          *mongo.Client
+	itemsColl       *mongo.Collection
+	pricesColl      *mongo.Collection
+	watchesColl     *mongo.Collection
+	leasesColl      *mongo.Collection
+	checkpointsColl *mongo.Collection
+	quarantineColl  *mongo.Collection
+	runsColl        *mongo.Collection
+	schedulesColl   *mongo.Collection
+	recrawlColl     *mongo.Collection
+	keywordsColl    *mongo.Collection
+	detailsColl     *mongo.Collection
+	opTimeout       time.Duration
+}
+
+func NewMongo(ctx context.Context, cfg model.Config) (*Mongo, error) {
+	client, err := database.ConnectMongo(ctx, cfg.MongoURI)
+	if err != nil {
+		return nil, err
+	}
+
+	db := client.Database(cfg.DBName)
+	m := &Mongo{
+		client:          client,
+		itemsColl:       db.Collection(cfg.ItemsColl),
+		pricesColl:      db.Collection(cfg.PricesColl),
+		watchesColl:     db.Collection(cfg.WatchesColl),
+		leasesColl:      db.Collection(cfg.LeasesColl),
+		checkpointsColl: db.Collection(cfg.CheckpointsColl),
+		quarantineColl:  db.Collection(cfg.QuarantineColl),
+		runsColl:        db.Collection(cfg.RunsColl),
+		schedulesColl:   db.Collection(cfg.SchedulesColl),
+		recrawlColl:     db.Collection(cfg.RecrawlColl),
+		keywordsColl:    db.Collection(cfg.KeywordsColl),
+		detailsColl:     db.Collection(cfg.DetailsColl),
+		opTimeout:       opTimeout(cfg),
+	}
+	if err := m.ensureIndexes(ctx); err != nil {
+		_ = client.Disconnect(ctx)
+		return nil, fmt.Errorf("ensure indexes: %w", err)
+	}
+	return m, nil
+}
+
+func (m *Mongo) ensureIndexes(ctx context.Context) error {
+	// Unique so that two workers upserting a new item cannot both insert
+	// it; see UpsertItem.
+	itemsIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "sources.id", Value: 1}, {Key: "sources.source", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err := m.itemsColl.Indexes().CreateOne(ctx, itemsIndex)
+	if indexConflict(err) {
+		// Databases made before the index was unique have it under the
+		// same name without the option.
+		if _, err := m.itemsColl.Indexes().DropOne(ctx, "sources.id_1_sources.source_1"); err != nil {
+			return fmt.Errorf("drop non-unique items index: %w", err)
+		}
+		_, err = m.itemsColl.Indexes().CreateOne(ctx, itemsIndex)
+	}
+	if err != nil {
+		return fmt.Errorf("items index (duplicate items must be merged first): %w", err)
+	}
+	_, err = m.pricesColl.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "itemID", Value: 1}, {Key: "date", Value: -1}}},
+		// EachPriceSince matches on either field.
+		{Keys: bson.D{{Key: "date", Value: -1}}},
+		{Keys: bson.D{{Key: "last_seen", Value: -1}}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = m.quarantineColl.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "source", Value: 1}, {Key: "created", Value: -1}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = m.runsColl.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "source", Value: 1}, {Key: "started", Value: -1}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = m.recrawlColl.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "kind", Value: 1}, {Key: "source", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = m.detailsColl.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "source", Value: 1}},
+	})
+	return err
+}
+
+// indexConflict reports whether err is from creating an index that
+// already exists with other options.
+func indexConflict(err error) bool {
+	var cmdErr mongo.CommandError
+	if !errors.As(err, &cmdErr) {
+		return false
+	}
+	return cmdErr.Code == 85 || cmdErr.Code == 86 // IndexOptionsConflict, IndexKeySpecsConflict
+}
+
+func (m *Mongo) UpsertItem(parentCtx context.Context, item *model.Item) (bool, error) {
+	defer metrics.ObserveDB(KindMongo, "upsert_item")()
+	ctx, cancel := context.WithTimeout(parentCtx, m.opTimeout)
+	defer cancel()
+
+	created, err := m.upsertItem(ctx, item)
+	if mongo.IsDuplicateKeyError(err) {
+		// Another worker inserted the item between our two updates; the
+		// second attempt finds it.
+		created, err = m.upsertItem(ctx, item)
+	}
+	return created, err
+}
+
+func (m *Mongo) upsertItem(ctx context.Context, item *model.Item) (bool, error) {
+	now := time.Now().UTC()
+	if item.Updated.IsZero() {
+		item.Updated = now
+	}
+	created := now
+	if !item.Created.IsZero() {
+		created = item.Created
+	}
+
+	filter := bson.M{
+		"sources.id":     item.SourceID,
+		"sources.source": item.Source,
+	}
+	if item.ID != "" {
+		oid, err := primitive.ObjectIDFromHex(item.ID)
+		if err != nil {
+			return false, fmt.Errorf("item id %q: %w", item.ID, err)
+		}
+		filter = bson.M{"_id": oid}
+	}
+	set := bson.M{
+		"title":   item.Title,
+		"images":  item.Images,
+		"link":    item.Link,
+		"brand":   item.Brand,
+		"updated": item.Updated,
+	}
+	// An unknown availability leaves the last known one in place.
+	if item.Availability != model.AvailabilityUnknown {
+		set["availability"] = string(item.Availability)
+	}
+
+	// Only an older item is overwritten, so replayed captures do not
+	// revert what a later scrape saw.
+	newer := bson.M{"$or": bson.A{
+		bson.M{"updated": bson.M{"$lt": item.Updated}},
+		bson.M{"updated": bson.M{"$exists": false}},
+	}}
+	for k, v := range filter {
+		newer[k] = v
+	}
+	var before mongoItem
+	err := m.itemsColl.FindOneAndUpdate(ctx, newer, bson.M{"$set": set}).Decode(&before)
+	if err == nil {
+		item.ID = before.ID.Hex()
+		if item.Availability == model.AvailabilityUnknown {
+			item.Availability = model.Availability(before.Availability)
+		}
+		return false, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return false, fmt.Errorf("findoneandupdate: %w", err)
+	}
+
+	// Either there is no such item or it is at least as recent as this
+	// one; only the first case writes anything.
+	set["created"] = created
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before)
+	err = m.itemsColl.FindOneAndUpdate(ctx, filter, bson.M{"$setOnInsert": set}, opts).Decode(&before)
+	if err == nil {
+		item.ID = before.ID.Hex()
+		item.Created = before.Created
+		if item.Availability == model.AvailabilityUnknown {
+			item.Availability = model.Availability(before.Availability)
+		}
+		return false, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return false, fmt.Errorf("findoneandupdate: %w", err)
+	}
+
+	var after mongoItem
+	if err := m.itemsColl.FindOne(ctx, filter).Decode(&after); err != nil {
+		return false, fmt.Errorf("find after upsert: %w", err)
+	}
+	item.ID = after.ID.Hex()
+	item.Created = after.Created
+	return true, nil
+}
+
+func (m *Mongo) Item(parentCtx context.Context, id string) (model.Item, error) {
+	defer metrics.ObserveDB(KindMongo, "item")()
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return model.Item{}, fmt.Errorf("item id %q: %w", id, err)
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, m.opTimeout)
+	defer cancel()
+
+	var doc mongoItem
+	if err := m.itemsColl.FindOne(ctx, bson.M{"_id": oid}).Decode(&doc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return model.Item{}, ErrNotFound
+		}
+		return model.Item{}, fmt.Errorf("find item: %w", err)
+	}
+	return doc.model(), nil
+}
+
+func (m *Mongo) EachItem(ctx context.Context, fn func(model.Item) error) error {
+	cursor, err := m.itemsColl.Find(ctx, bson.M{})
+	if err != nil {
+		return fmt.Errorf("find items: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc mongoItem
+		if err := cursor.Decode(&doc); err != nil {
+			return fmt.Errorf("decode item: %w", err)
+		}
+		if err := fn(doc.model()); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func (m *Mongo) Brands(ctx context.Context) ([]string, error) {
+	defer metrics.ObserveDB(KindMongo, "brands")()
+	filter := bson.M{
+		"brand": bson.M{"$exists": true, "$nin": bson.A{"", "."}},
+	}
+	values, err := m.itemsColl.Distinct(ctx, "brand", filter)
+	if err != nil {
+		return nil, fmt.Errorf("distinct brands: %w", err)
+	}
+
+	brands := make([]string, 0, len(values))
+	for _, v := range values {
+		if b, ok := v.(string); ok {
+			brands = append(brands, b)
+		}
+	}
+	return brands, nil
+}
+
+func (m *Mongo) AppendPrice(parentCtx context.Context, price model.Price) error {
+	defer metrics.ObserveDB(KindMongo, "append_price")()
+	oid, err := primitive.ObjectIDFromHex(price.ItemID)
+	if err != nil {
+		return fmt.Errorf("item id %q: %w", price.ItemID, err)
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, m.opTimeout)
+	defer cancel()
+
+	doc := mongoPrice{
+		ItemID:       oid,
+		Date:         price.Date,
+		LastSeen:     price.LastSeen,
+		Currency:     price.Currency,
+		Price:        price.Price,
+		ListPrice:    price.ListPrice,
+		Savings:      price.Savings,
+		DiscountPct:  price.DiscountPct,
+		Availability: string(price.Availability),
+		LeadTime:     price.LeadTime,
+	}
+	if _, err := m.pricesColl.InsertOne(ctx, doc); err != nil {
+		return fmt.Errorf("insert price: %w", err)
+	}
+	return nil
+}
+
+func (m *Mongo) TouchPrice(parentCtx context.Context, price model.Price, seen time.Time) error {
+	defer metrics.ObserveDB(KindMongo, "touch_price")()
+	oid, err := primitive.ObjectIDFromHex(price.ItemID)
+	if err != nil {
+		return fmt.Errorf("item id %q: %w", price.ItemID, err)
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, m.opTimeout)
+	defer cancel()
+
+	filter := bson.M{"itemID": oid, "date": price.Date}
+	if _, err := m.pricesColl.UpdateOne(ctx, filter, bson.M{"$max": bson.M{"last_seen": seen}}); err != nil {
+		return fmt.Errorf("touch price: %w", err)
+	}
+	return nil
+}
+
+func (m *Mongo) LatestPrice(parentCtx context.Context, itemID string, at time.Time) (model.Price, error) {
+	defer metrics.ObserveDB(KindMongo, "latest_price")()
+	oid, err := primitive.ObjectIDFromHex(itemID)
+	if err != nil {
+		return model.Price{}, fmt.Errorf("item id %q: %w", itemID, err)
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, m.opTimeout)
+	defer cancel()
+
+	var doc mongoPrice
+	opts := options.FindOne().SetSort(bson.D{{Key: "date", Value: -1}})
+	filter := bson.M{"itemID": oid, "date": bson.M{"$lte": at}}
+	if err := m.pricesColl.FindOne(ctx, filter, opts).Decode(&doc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return model.Price{}, ErrNotFound
+		}
+		return model.Price{}, fmt.Errorf("find latest price: %w", err)
+	}
+	return doc.model(), nil
+}
+
+func (m *Mongo) PriceHistory(ctx context.Context, itemID string) ([]model.Price, error) {
+	defer metrics.ObserveDB(KindMongo, "price_history")()
+	oid, err := primitive.ObjectIDFromHex(itemID)
+	if err != nil {
+		return nil, fmt.Errorf("item id %q: %w", itemID, err)
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: 1}})
+	cursor, err := m.pricesColl.Find(ctx, bson.M{"itemID": oid}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("find prices: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var prices []model.Price
+	for cursor.Next(ctx) {
+		var doc mongoPrice
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("decode price: %w", err)
+		}
+		prices = append(prices, doc.model())
+	}
+	return prices, cursor.Err()
+}
+
+func (m *Mongo) EachPrice(ctx context.Context, fn func(model.Price) error) error {
+	return m.eachPrice(ctx, bson.M{}, fn)
+}
+
+func (m *Mongo) EachPriceSince(ctx context.Context, since time.Time, fn func(model.Price) error) error {
+	defer metrics.ObserveDB(KindMongo, "each_price_since")()
+	return m.eachPrice(ctx, bson.M{"$or": bson.A{
+		bson.M{"date": bson.M{"$gte": since}},
+		bson.M{"last_seen": bson.M{"$gte": since}},
+	}}, fn)
+}
+
+func (m *Mongo) eachPrice(ctx context.Context, filter bson.M, fn func(model.Price) error) error {
+	cursor, err := m.pricesColl.Find(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("find prices: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc mongoPrice
+		if err := cursor.Decode(&doc); err != nil {
+			return fmt.Errorf("decode price: %w", err)
+		}
+		if err := fn(doc.model()); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func (m *Mongo) ListWatches(ctx context.Context) ([]model.Watch, error) {
+	defer metrics.ObserveDB(KindMongo, "list_watches")()
+	cursor, err := m.watchesColl.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("find watches: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var watches []model.Watch
+	for cursor.Next(ctx) {
+		var doc mongoWatch
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("decode watch: %w", err)
+		}
+		watches = append(watches, model.Watch(doc))
+	}
+	return watches, cursor.Err()
+}
+
+func (m *Mongo) Stats(ctx context.Context) (model.Stats, error) {
+	defer metrics.ObserveDB(KindMongo, "stats")()
+	stats := model.Stats{ItemsBySource: map[string]int64{}}
+
+	cursor, err := m.itemsColl.Aggregate(ctx, bson.A{
+		bson.M{"$group": bson.M{"_id": "$sources.source", "count": bson.M{"$sum": 1}}},
+	})
+	if err != nil {
+		return stats, fmt.Errorf("count items: %w", err)
+	}
+	var bySource []struct {
+		Source string `bson:"_id"`
+		Count  int64  `bson:"count"`
+	}
+	if err := cursor.All(ctx, &bySource); err != nil {
+		return stats, fmt.Errorf("count items: %w", err)
+	}
+	for _, s := range bySource {
+		stats.ItemsBySource[s.Source] = s.Count
+	}
+
+	stats.Prices, err = m.pricesColl.EstimatedDocumentCount(ctx)
+	if err != nil {
+		return stats, fmt.Errorf("count prices: %w", err)
+	}
+
+	var latest mongoPrice
+	opts := options.FindOne().SetSort(bson.D{{Key: "date", Value: -1}})
+	err = m.pricesColl.FindOne(ctx, bson.M{}, opts).Decode(&latest)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return stats, fmt.Errorf("latest price: %w", err)
+	}
+	stats.LatestPrice = latest.Date
+	return stats, nil
+}
+
+func (m *Mongo) Ping(ctx context.Context) error {
+	defer metrics.ObserveDB(KindMongo, "ping")()
+	return m.client.Ping(ctx, nil)
+}
+
+func (m *Mongo) Close(ctx context.Context) error {
+	return m.client.Disconnect(ctx)
+}
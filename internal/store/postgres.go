@@ -0,0 +1,323 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mindsgn-studio/takealot-scraper/internal/database"
+	"github.com/mindsgn-studio/takealot-scraper/internal/metrics"
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+)
+
+// Postgres stores items in the app's items, prices and watch tables. The
+// items table has no column for the retailer's own ID, so items without an
+// ID are matched on source and link instead. Neither table has columns for
+// availability, which is not kept.
+type Postgres struct {
+	db        *sql.DB
+	opTimeout time.Duration
+}
+
+func NewPostgres(ctx context.Context, cfg model.Config) (*Postgres, error) {
+	db, err := database.ConnectPostgres(ctx, cfg.PostgresURI)
+	if err != nil {
+		return nil, err
+	}
+	return &Postgres{db: db, opTimeout: opTimeout(cfg)}, nil
+}
+
+func (p *Postgres) UpsertItem(parentCtx context.Context, item *model.Item) (bool, error) {
+	defer metrics.ObserveDB(KindPostgres, "upsert_item")()
+	ctx, cancel := context.WithTimeout(parentCtx, p.opTimeout)
+	defer cancel()
+
+	created := false
+	if item.ID == "" {
+		err := p.db.QueryRowContext(ctx,
+			`SELECT uuid FROM items WHERE source_name = $1 AND link = $2 LIMIT 1`,
+			item.Source, item.Link,
+		).Scan(&item.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			item.ID = uuid.NewString()
+			created = true
+		} else if err != nil {
+			return false, fmt.Errorf("find item: %w", err)
+		}
+	}
+
+	image := ""
+	if len(item.Images) > 0 {
+		image = item.Images[0]
+	}
+
+	updated := item.Updated
+	if updated.IsZero() {
+		updated = time.Now().UTC()
+	}
+
+	// Only an older row is overwritten, so replayed captures do not revert
+	// what a later scrape saw.
+	query := `
+		INSERT INTO items (uuid, title, brand, link, source_name, image, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (uuid) DO UPDATE SET
+			title = EXCLUDED.title,
+			brand = EXCLUDED.brand,
+			link = EXCLUDED.link,
+			source_name = EXCLUDED.source_name,
+			image = EXCLUDED.image,
+			updated_at = EXCLUDED.updated_at
+		WHERE items.updated_at IS NULL OR items.updated_at < EXCLUDED.updated_at
+	`
+	_, err := p.db.ExecContext(ctx, query, item.ID, item.Title, item.Brand, item.Link, item.Source, image, updated)
+	if err != nil {
+		return false, fmt.Errorf("upsert item %s: %w", item.ID, err)
+	}
+	return created, nil
+}
+
+func (p *Postgres) Item(parentCtx context.Context, id string) (model.Item, error) {
+	defer metrics.ObserveDB(KindPostgres, "item")()
+	ctx, cancel := context.WithTimeout(parentCtx, p.opTimeout)
+	defer cancel()
+
+	row := p.db.QueryRowContext(ctx, `SELECT uuid, title, brand, link, source_name, image FROM items WHERE uuid = $1`, id)
+	item, err := scanItem(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Item{}, ErrNotFound
+	}
+	return item, err
+}
+
+func (p *Postgres) EachItem(ctx context.Context, fn func(model.Item) error) error {
+	rows, err := p.db.QueryContext(ctx, `SELECT uuid, title, brand, link, source_name, image FROM items`)
+	if err != nil {
+		return fmt.Errorf("query items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanItem(row rowScanner) (model.Item, error) {
+	var (
+		item  model.Item
+		title sql.NullString
+		brand sql.NullString
+		image sql.NullString
+	)
+	if err := row.Scan(&item.ID, &title, &brand, &item.Link, &item.Source, &image); err != nil {
+		return model.Item{}, err
+	}
+	item.Title = title.String
+	item.Brand = brand.String
+	if image.String != "" {
+		item.Images = []string{image.String}
+	}
+	return item, nil
+}
+
+func (p *Postgres) Brands(ctx context.Context) ([]string, error) {
+	defer metrics.ObserveDB(KindPostgres, "brands")()
+	rows, err := p.db.QueryContext(ctx, `SELECT DISTINCT brand FROM items WHERE brand IS NOT NULL AND brand NOT IN ('', '.')`)
+	if err != nil {
+		return nil, fmt.Errorf("query brands: %w", err)
+	}
+	defer rows.Close()
+
+	var brands []string
+	for rows.Next() {
+		var brand string
+		if err := rows.Scan(&brand); err != nil {
+			return nil, fmt.Errorf("scan brand: %w", err)
+		}
+		brands = append(brands, brand)
+	}
+	return brands, rows.Err()
+}
+
+// AppendPrice relies on the prices table's unique date constraint, so
+// re-syncing the same history updates rows rather than duplicating them.
+// The table has no columns for list prices, so only the selling price is
+// kept.
+func (p *Postgres) AppendPrice(parentCtx context.Context, price model.Price) error {
+	defer metrics.ObserveDB(KindPostgres, "append_price")()
+	ctx, cancel := context.WithTimeout(parentCtx, p.opTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO prices (item_id, price, date) VALUES ($1, $2, $3)
+		ON CONFLICT (date) DO UPDATE SET
+			item_id = EXCLUDED.item_id,
+			price = EXCLUDED.price,
+			date = EXCLUDED.date,
+			updated_at = CURRENT_TIMESTAMP
+	`
+	if _, err := p.db.ExecContext(ctx, query, price.ItemID, price.Price, price.Date); err != nil {
+		return fmt.Errorf("insert price for item %s: %w", price.ItemID, err)
+	}
+	return nil
+}
+
+// TouchPrice records the sighting in updated_at, the table's only column for
+// it.
+func (p *Postgres) TouchPrice(parentCtx context.Context, price model.Price, seen time.Time) error {
+	defer metrics.ObserveDB(KindPostgres, "touch_price")()
+	ctx, cancel := context.WithTimeout(parentCtx, p.opTimeout)
+	defer cancel()
+
+	query := `UPDATE prices SET updated_at = $3 WHERE item_id = $1 AND date = $2`
+	if _, err := p.db.ExecContext(ctx, query, price.ItemID, price.Date, seen); err != nil {
+		return fmt.Errorf("touch price for item %s: %w", price.ItemID, err)
+	}
+	return nil
+}
+
+func (p *Postgres) LatestPrice(parentCtx context.Context, itemID string, at time.Time) (model.Price, error) {
+	defer metrics.ObserveDB(KindPostgres, "latest_price")()
+	ctx, cancel := context.WithTimeout(parentCtx, p.opTimeout)
+	defer cancel()
+
+	price := model.Price{ItemID: itemID, Currency: "zar"}
+	err := p.db.QueryRowContext(ctx,
+		`SELECT price, date FROM prices WHERE item_id = $1 AND date <= $2 ORDER BY date DESC LIMIT 1`, itemID, at,
+	).Scan(&price.Price, &price.Date)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Price{}, ErrNotFound
+	}
+	if err != nil {
+		return model.Price{}, fmt.Errorf("latest price: %w", err)
+	}
+	return price, nil
+}
+
+func (p *Postgres) PriceHistory(ctx context.Context, itemID string) ([]model.Price, error) {
+	defer metrics.ObserveDB(KindPostgres, "price_history")()
+	rows, err := p.db.QueryContext(ctx, `SELECT item_id, price, date FROM prices WHERE item_id = $1 ORDER BY date ASC`, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("query prices: %w", err)
+	}
+	defer rows.Close()
+	return scanPrices(rows, nil)
+}
+
+func (p *Postgres) EachPrice(ctx context.Context, fn func(model.Price) error) error {
+	rows, err := p.db.QueryContext(ctx, `SELECT item_id, price, date FROM prices`)
+	if err != nil {
+		return fmt.Errorf("query prices: %w", err)
+	}
+	defer rows.Close()
+	_, err = scanPrices(rows, fn)
+	return err
+}
+
+// EachPriceSince treats updated_at, where TouchPrice records sightings, as
+// the point's last seen time.
+func (p *Postgres) EachPriceSince(ctx context.Context, since time.Time, fn func(model.Price) error) error {
+	defer metrics.ObserveDB(KindPostgres, "each_price_since")()
+	rows, err := p.db.QueryContext(ctx, `SELECT item_id, price, date FROM prices WHERE date >= $1 OR updated_at >= $1`, since)
+	if err != nil {
+		return fmt.Errorf("query prices: %w", err)
+	}
+	defer rows.Close()
+	_, err = scanPrices(rows, fn)
+	return err
+}
+
+// scanPrices collects rows into a slice, or streams them to fn when set.
+func scanPrices(rows *sql.Rows, fn func(model.Price) error) ([]model.Price, error) {
+	var prices []model.Price
+	for rows.Next() {
+		price := model.Price{Currency: "zar"}
+		if err := rows.Scan(&price.ItemID, &price.Price, &price.Date); err != nil {
+			return nil, fmt.Errorf("scan price: %w", err)
+		}
+		if fn != nil {
+			if err := fn(price); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		prices = append(prices, price)
+	}
+	return prices, rows.Err()
+}
+
+func (p *Postgres) ListWatches(ctx context.Context) ([]model.Watch, error) {
+	defer metrics.ObserveDB(KindPostgres, "list_watches")()
+	rows, err := p.db.QueryContext(ctx, `SELECT item_id, token, device FROM watch`)
+	if err != nil {
+		return nil, fmt.Errorf("query watch: %w", err)
+	}
+	defer rows.Close()
+
+	var watches []model.Watch
+	for rows.Next() {
+		var itemID, token, device sql.NullString
+		if err := rows.Scan(&itemID, &token, &device); err != nil {
+			return nil, fmt.Errorf("scan watch: %w", err)
+		}
+		watches = append(watches, model.Watch{
+			ItemID: itemID.String,
+			Token:  token.String,
+			Device: device.String,
+		})
+	}
+	return watches, rows.Err()
+}
+
+func (p *Postgres) Stats(ctx context.Context) (model.Stats, error) {
+	defer metrics.ObserveDB(KindPostgres, "stats")()
+	stats := model.Stats{ItemsBySource: map[string]int64{}}
+
+	rows, err := p.db.QueryContext(ctx, `SELECT source_name, COUNT(*) FROM items GROUP BY source_name`)
+	if err != nil {
+		return stats, fmt.Errorf("count items: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var source sql.NullString
+		var count int64
+		if err := rows.Scan(&source, &count); err != nil {
+			return stats, fmt.Errorf("scan item count: %w", err)
+		}
+		stats.ItemsBySource[source.String] = count
+	}
+	if err := rows.Err(); err != nil {
+		return stats, err
+	}
+
+	var latest sql.NullTime
+	err = p.db.QueryRowContext(ctx, `SELECT COUNT(*), MAX(date) FROM prices`).Scan(&stats.Prices, &latest)
+	if err != nil {
+		return stats, fmt.Errorf("count prices: %w", err)
+	}
+	stats.LatestPrice = latest.Time
+	return stats, nil
+}
+
+func (p *Postgres) Ping(ctx context.Context) error {
+	defer metrics.ObserveDB(KindPostgres, "ping")()
+	return p.db.PingContext(ctx)
+}
+
+func (p *Postgres) Close(context.Context) error {
+	return p.db.Close()
+}
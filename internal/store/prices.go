@@ -0,0 +1,90 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+)
+
+// PriceWrite says what RecordPrice did with a price.
+type PriceWrite int
+
+const (
+	// PriceTouched extended the latest point's LastSeen.
+	PriceTouched PriceWrite = iota
+	// PriceFirst wrote the item's first point.
+	PriceFirst
+	// PriceChanged wrote a point with a different price or availability.
+	PriceChanged
+	// PriceRepeated wrote an unchanged price because the dedup window had
+	// passed.
+	PriceRepeated
+)
+
+// Inserted reports whether a new point was written.
+func (w PriceWrite) Inserted() bool {
+	return w != PriceTouched
+}
+
+// RecordPrice writes price as a new point only when it differs from the
+// item's latest point before it or window has passed since that point was
+// created. Otherwise that point's LastSeen is moved forward. Comparing with
+// the point before price.Date, rather than the newest overall, lets
+// reprocessed history be recorded more than once without duplicates.
+//
+// Calls for the same item are serialised, so workers that see an item under
+// different keywords in the same pass do not both write its "first" or
+// "changed" point.
+func RecordPrice(ctx context.Context, st Store, price model.Price, window time.Duration) (PriceWrite, error) {
+	if price.LastSeen.IsZero() {
+		price.LastSeen = price.Date
+	}
+
+	mu := priceLock(price.ItemID)
+	mu.Lock()
+	defer mu.Unlock()
+
+	latest, err := st.LatestPrice(ctx, price.ItemID, price.Date)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return PriceTouched, err
+	}
+
+	write := PriceFirst
+	if err == nil {
+		if !samePrice(latest, price) {
+			write = PriceChanged
+		} else if price.Date.Sub(latest.Date) < window {
+			return PriceTouched, st.TouchPrice(ctx, latest, price.LastSeen)
+		} else {
+			write = PriceRepeated
+		}
+	}
+	return write, st.AppendPrice(ctx, price)
+}
+
+// priceLocks stripe RecordPrice's read-then-write by item ID.
+var priceLocks [64]sync.Mutex
+
+func priceLock(itemID string) *sync.Mutex {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(itemID))
+	return &priceLocks[h.Sum32()%uint32(len(priceLocks))]
+}
+
+// samePrice compares list prices and availability only when both points
+// have them, so stores and pages that do not keep them do not read as a
+// change on every sighting. A change in availability alone is a new point,
+// which is how the history records items selling out and coming back.
+func samePrice(a, b model.Price) bool {
+	if a.Price != b.Price || a.Currency != b.Currency {
+		return false
+	}
+	if a.ListPrice != 0 && b.ListPrice != 0 && a.ListPrice != b.ListPrice {
+		return false
+	}
+	return a.Availability == model.AvailabilityUnknown || b.Availability == model.AvailabilityUnknown || a.Availability == b.Availability
+}
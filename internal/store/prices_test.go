@@ -0,0 +1,167 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+)
+
+func TestRecordPrice(t *testing.T) {
+	day := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	point := func(at time.Time, price float64, availability model.Availability) model.Price {
+		return model.Price{ItemID: "item", Date: at, LastSeen: at, Currency: "zar", Price: price, Availability: availability}
+	}
+	const window = time.Hour
+
+	tests := []struct {
+		name    string
+		history []model.Price
+		price   model.Price
+		want    PriceWrite
+		// points is the length of the history afterwards.
+		points int
+		// lastSeen is the LastSeen of the point at index touched, when set.
+		touched  int
+		lastSeen time.Time
+	}{
+		{
+			name:   "first point",
+			price:  point(day, 100, model.InStock),
+			want:   PriceFirst,
+			points: 1,
+		},
+		{
+			name:    "changed price",
+			history: []model.Price{point(day, 100, model.InStock)},
+			price:   point(day.Add(time.Minute), 90, model.InStock),
+			want:    PriceChanged,
+			points:  2,
+		},
+		{
+			name:    "availability flip",
+			history: []model.Price{point(day, 100, model.InStock)},
+			price:   point(day.Add(time.Minute), 100, model.OutOfStock),
+			want:    PriceChanged,
+			points:  2,
+		},
+		{
+			name:     "unknown availability is not a change",
+			history:  []model.Price{point(day, 100, model.InStock)},
+			price:    point(day.Add(time.Minute), 100, model.AvailabilityUnknown),
+			want:     PriceTouched,
+			points:   1,
+			lastSeen: day.Add(time.Minute),
+		},
+		{
+			name:     "unchanged inside dedup window",
+			history:  []model.Price{point(day, 100, model.InStock)},
+			price:    point(day.Add(30*time.Minute), 100, model.InStock),
+			want:     PriceTouched,
+			points:   1,
+			lastSeen: day.Add(30 * time.Minute),
+		},
+		{
+			name:    "unchanged after dedup window",
+			history: []model.Price{point(day, 100, model.InStock)},
+			price:   point(day.Add(2*time.Hour), 100, model.InStock),
+			want:    PriceRepeated,
+			points:  2,
+		},
+		{
+			name:    "reprocessed older date compares with the point before it",
+			history: []model.Price{point(day, 100, model.InStock), point(day.Add(24*time.Hour), 80, model.InStock)},
+			price:   point(day.Add(10*time.Minute), 100, model.InStock),
+			want:    PriceTouched,
+			points:  2,
+			// The newer point is left alone.
+			lastSeen: day.Add(10 * time.Minute),
+		},
+		{
+			name:    "reprocessed date before all history",
+			history: []model.Price{point(day, 100, model.InStock)},
+			price:   point(day.Add(-24*time.Hour), 120, model.InStock),
+			want:    PriceFirst,
+			points:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			st := NewMemory()
+			for _, p := range tt.history {
+				if err := st.AppendPrice(ctx, p); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := RecordPrice(ctx, st, tt.price, window)
+			if err != nil {
+				t.Fatalf("RecordPrice: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("write = %v, want %v", got, tt.want)
+			}
+			history, _ := st.PriceHistory(ctx, "item")
+			if len(history) != tt.points {
+				t.Fatalf("history has %d points, want %d: %+v", len(history), tt.points, history)
+			}
+			if !tt.lastSeen.IsZero() && !history[tt.touched].LastSeen.Equal(tt.lastSeen) {
+				t.Errorf("LastSeen = %v, want %v", history[tt.touched].LastSeen, tt.lastSeen)
+			}
+		})
+	}
+}
+
+func TestRecordPriceConcurrentFirst(t *testing.T) {
+	ctx := context.Background()
+	st := NewMemory()
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			price := model.Price{ItemID: "item", Date: at, Currency: "zar", Price: 100}
+			if _, err := RecordPrice(ctx, st, price, time.Hour); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	history, _ := st.PriceHistory(ctx, "item")
+	if len(history) != 1 {
+		t.Fatalf("history has %d points, want 1", len(history))
+	}
+}
+
+func TestSamePrice(t *testing.T) {
+	base := model.Price{Currency: "zar", Price: 100, ListPrice: 120, Availability: model.InStock}
+	tests := []struct {
+		name string
+		edit func(*model.Price)
+		want bool
+	}{
+		{"identical", func(*model.Price) {}, true},
+		{"price", func(p *model.Price) { p.Price = 99 }, false},
+		{"currency", func(p *model.Price) { p.Currency = "usd" }, false},
+		{"list price", func(p *model.Price) { p.ListPrice = 130 }, false},
+		{"list price missing", func(p *model.Price) { p.ListPrice = 0 }, true},
+		{"availability", func(p *model.Price) { p.Availability = model.LimitedStock }, false},
+		{"availability unknown", func(p *model.Price) { p.Availability = model.AvailabilityUnknown }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			other := base
+			tt.edit(&other)
+			if got := samePrice(base, other); got != tt.want {
+				t.Errorf("samePrice = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/metrics"
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+)
+
+// Quarantiner keeps payloads that failed extraction.
+type Quarantiner interface {
+	Quarantine(ctx context.Context, q model.Quarantined) error
+}
+
+type mongoQuarantined struct {
+	Source    string    `bson:"source"`
+	Keyword   string    `bson:"keyword"`
+	Page      int       `bson:"page"`
+	Cursor    string    `bson:"cursor,omitempty"`
+	ProductID string    `bson:"product_id,omitempty"`
+	Reason    string    `bson:"reason"`
+	Sample    string    `bson:"sample"`
+	Created   time.Time `bson:"created"`
+}
+
+func (m *Mongo) Quarantine(parentCtx context.Context, q model.Quarantined) error {
+	defer metrics.ObserveDB(KindMongo, "quarantine")()
+	ctx, cancel := context.WithTimeout(parentCtx, m.opTimeout)
+	defer cancel()
+
+	if q.Created.IsZero() {
+		q.Created = time.Now().UTC()
+	}
+	doc := mongoQuarantined{
+		Source:    q.Source,
+		Keyword:   q.Keyword,
+		Page:      q.Page,
+		Cursor:    q.Cursor,
+		ProductID: q.ProductID,
+		Reason:    q.Reason,
+		Sample:    q.Sample,
+		Created:   q.Created,
+	}
+	if _, err := m.quarantineColl.InsertOne(ctx, doc); err != nil {
+		return fmt.Errorf("insert quarantined: %w", err)
+	}
+	return nil
+}
+
+func (m *Memory) Quarantine(_ context.Context, q model.Quarantined) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if q.Created.IsZero() {
+		q.Created = time.Now().UTC()
+	}
+	m.quarantine = append(m.quarantine, q)
+	return nil
+}
@@ -0,0 +1,110 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/metrics"
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Pacer persists the learned recrawl interval of each keyword and item.
+type Pacer interface {
+	// Recrawls returns every record of kind for source, by key.
+	Recrawls(ctx context.Context, kind, source string) (map[string]model.Recrawl, error)
+	SaveRecrawl(ctx context.Context, r model.Recrawl) error
+}
+
+type mongoRecrawl struct {
+	ID          string        `bson:"_id"`
+	Kind        string        `bson:"kind"`
+	Source      string        `bson:"source"`
+	Key         string        `bson:"key"`
+	Interval    time.Duration `bson:"interval"`
+	NextDue     time.Time     `bson:"next_due_at"`
+	LastChanged time.Time     `bson:"last_changed,omitempty"`
+	Updated     time.Time     `bson:"updated"`
+}
+
+func recrawlKey(kind, source, key string) string {
+	return kind + "\x00" + keywordKey(source, key)
+}
+
+func (m *Mongo) Recrawls(ctx context.Context, kind, source string) (map[string]model.Recrawl, error) {
+	defer metrics.ObserveDB(KindMongo, "recrawls")()
+	cursor, err := m.recrawlColl.Find(ctx, bson.M{"kind": kind, "source": source})
+	if err != nil {
+		return nil, fmt.Errorf("find recrawls: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	recrawls := make(map[string]model.Recrawl)
+	for cursor.Next(ctx) {
+		var doc mongoRecrawl
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("decode recrawl: %w", err)
+		}
+		recrawls[doc.Key] = model.Recrawl{
+			Kind:        doc.Kind,
+			Source:      doc.Source,
+			Key:         doc.Key,
+			Interval:    doc.Interval,
+			NextDue:     doc.NextDue,
+			LastChanged: doc.LastChanged,
+			Updated:     doc.Updated,
+		}
+	}
+	return recrawls, cursor.Err()
+}
+
+func (m *Mongo) SaveRecrawl(parentCtx context.Context, r model.Recrawl) error {
+	defer metrics.ObserveDB(KindMongo, "save_recrawl")()
+	ctx, cancel := context.WithTimeout(parentCtx, m.opTimeout)
+	defer cancel()
+
+	if r.Updated.IsZero() {
+		r.Updated = time.Now().UTC()
+	}
+	doc := mongoRecrawl{
+		ID:          recrawlKey(r.Kind, r.Source, r.Key),
+		Kind:        r.Kind,
+		Source:      r.Source,
+		Key:         r.Key,
+		Interval:    r.Interval,
+		NextDue:     r.NextDue,
+		LastChanged: r.LastChanged,
+		Updated:     r.Updated,
+	}
+	opts := options.Replace().SetUpsert(true)
+	if _, err := m.recrawlColl.ReplaceOne(ctx, bson.M{"_id": doc.ID}, doc, opts); err != nil {
+		return fmt.Errorf("save recrawl: %w", err)
+	}
+	return nil
+}
+
+func (m *Memory) Recrawls(_ context.Context, kind, source string) (map[string]model.Recrawl, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	recrawls := make(map[string]model.Recrawl)
+	for _, r := range m.recrawls {
+		if r.Kind == kind && r.Source == source {
+			recrawls[r.Key] = r
+		}
+	}
+	return recrawls, nil
+}
+
+func (m *Memory) SaveRecrawl(_ context.Context, r model.Recrawl) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if r.Updated.IsZero() {
+		r.Updated = time.Now().UTC()
+	}
+	m.recrawls[recrawlKey(r.Kind, r.Source, r.Key)] = r
+	return nil
+}
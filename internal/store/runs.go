@@ -0,0 +1,136 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sort"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/metrics"
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Ledger keeps a summary of every scrape run.
+type Ledger interface {
+	// SaveRun inserts or replaces the run with run.ID.
+	SaveRun(ctx context.Context, run model.Run) error
+	Run(ctx context.Context, id string) (model.Run, error)
+	// ListRuns returns up to limit runs, newest first. An empty source
+	// lists every source.
+	ListRuns(ctx context.Context, source string, limit int) ([]model.Run, error)
+}
+
+type mongoRun struct {
+	ID           string           `bson:"_id"`
+	Pass         string           `bson:"pass"`
+	Source       string           `bson:"source"`
+	Host         string           `bson:"host"`
+	WorkerID     string           `bson:"worker_id"`
+	Started      time.Time        `bson:"started"`
+	Finished     time.Time        `bson:"finished,omitempty"`
+	Keywords     int64            `bson:"keywords"`
+	Pages        int64            `bson:"pages"`
+	ItemsNew     int64            `bson:"items_new"`
+	ItemsUpdated int64            `bson:"items_updated"`
+	Prices       int64            `bson:"prices"`
+	Errors       map[string]int64 `bson:"errors,omitempty"`
+	Exit         string           `bson:"exit"`
+	Error        string           `bson:"error,omitempty"`
+}
+
+func (d mongoRun) model() model.Run {
+	return model.Run(d)
+}
+
+func (m *Mongo) SaveRun(parentCtx context.Context, run model.Run) error {
+	defer metrics.ObserveDB(KindMongo, "save_run")()
+	ctx, cancel := context.WithTimeout(parentCtx, m.opTimeout)
+	defer cancel()
+
+	opts := options.Replace().SetUpsert(true)
+	if _, err := m.runsColl.ReplaceOne(ctx, bson.M{"_id": run.ID}, mongoRun(run), opts); err != nil {
+		return fmt.Errorf("save run: %w", err)
+	}
+	return nil
+}
+
+func (m *Mongo) Run(parentCtx context.Context, id string) (model.Run, error) {
+	defer metrics.ObserveDB(KindMongo, "run")()
+	ctx, cancel := context.WithTimeout(parentCtx, m.opTimeout)
+	defer cancel()
+
+	var doc mongoRun
+	if err := m.runsColl.FindOne(ctx, bson.M{"_id": id}).Decode(&doc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return model.Run{}, ErrNotFound
+		}
+		return model.Run{}, fmt.Errorf("find run: %w", err)
+	}
+	return doc.model(), nil
+}
+
+func (m *Mongo) ListRuns(ctx context.Context, source string, limit int) ([]model.Run, error) {
+	defer metrics.ObserveDB(KindMongo, "list_runs")()
+	filter := bson.M{}
+	if source != "" {
+		filter["source"] = source
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "started", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := m.runsColl.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("find runs: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var runs []model.Run
+	for cursor.Next(ctx) {
+		var doc mongoRun
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("decode run: %w", err)
+		}
+		runs = append(runs, doc.model())
+	}
+	return runs, cursor.Err()
+}
+
+func (m *Memory) SaveRun(_ context.Context, run model.Run) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	run.Errors = maps.Clone(run.Errors)
+	m.runs[run.ID] = run
+	return nil
+}
+
+func (m *Memory) Run(_ context.Context, id string) (model.Run, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	run, ok := m.runs[id]
+	if !ok {
+		return model.Run{}, ErrNotFound
+	}
+	return run, nil
+}
+
+func (m *Memory) ListRuns(_ context.Context, source string, limit int) ([]model.Run, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var runs []model.Run
+	for _, run := range m.runs {
+		if source == "" || run.Source == source {
+			runs = append(runs, run)
+		}
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].Started.After(runs[j].Started) })
+	if limit > 0 && len(runs) > limit {
+		runs = runs[:limit]
+	}
+	return runs, nil
+}
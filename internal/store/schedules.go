@@ -0,0 +1,74 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/metrics"
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Timetable persists when the daemon's jobs last ran and are next due.
+type Timetable interface {
+	Schedule(ctx context.Context, name string) (model.Schedule, error)
+	SaveSchedule(ctx context.Context, s model.Schedule) error
+}
+
+type mongoSchedule struct {
+	Name         string    `bson:"_id"`
+	LastStarted  time.Time `bson:"last_started"`
+	LastFinished time.Time `bson:"last_finished"`
+	LastError    string    `bson:"last_error,omitempty"`
+	NextDue      time.Time `bson:"next_due"`
+}
+
+func (m *Mongo) Schedule(parentCtx context.Context, name string) (model.Schedule, error) {
+	defer metrics.ObserveDB(KindMongo, "schedule")()
+	ctx, cancel := context.WithTimeout(parentCtx, m.opTimeout)
+	defer cancel()
+
+	var doc mongoSchedule
+	if err := m.schedulesColl.FindOne(ctx, bson.M{"_id": name}).Decode(&doc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return model.Schedule{}, ErrNotFound
+		}
+		return model.Schedule{}, fmt.Errorf("find schedule: %w", err)
+	}
+	return model.Schedule(doc), nil
+}
+
+func (m *Mongo) SaveSchedule(parentCtx context.Context, s model.Schedule) error {
+	defer metrics.ObserveDB(KindMongo, "save_schedule")()
+	ctx, cancel := context.WithTimeout(parentCtx, m.opTimeout)
+	defer cancel()
+
+	opts := options.Replace().SetUpsert(true)
+	if _, err := m.schedulesColl.ReplaceOne(ctx, bson.M{"_id": s.Name}, mongoSchedule(s), opts); err != nil {
+		return fmt.Errorf("save schedule: %w", err)
+	}
+	return nil
+}
+
+func (m *Memory) Schedule(_ context.Context, name string) (model.Schedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.schedules[name]
+	if !ok {
+		return model.Schedule{}, ErrNotFound
+	}
+	return s, nil
+}
+
+func (m *Memory) SaveSchedule(_ context.Context, s model.Schedule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.schedules[s.Name] = s
+	return nil
+}
@@ -0,0 +1,82 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/config"
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+)
+
+const (
+	DefaultDBOpTimeout = config.DefaultDBOpTimeout
+
+	KindMongo    = "mongo"
+	KindPostgres = "postgres"
+	KindMemory   = "memory"
+)
+
+var ErrNotFound = errors.New("not found")
+
+// Store is the persistence layer shared by the scrapers, watchers and sync.
+type Store interface {
+	// UpsertItem inserts or updates the item identified by Source and
+	// SourceID (or by ID when set) and fills in item.ID. A new item's
+	// Created is kept when set, so backfills keep their capture time. A
+	// stored item is only overwritten when item.Updated is newer than its
+	// own, so replaying old captures adds history without reverting it.
+	UpsertItem(ctx context.Context, item *model.Item) (created bool, err error)
+	Item(ctx context.Context, id string) (model.Item, error)
+	EachItem(ctx context.Context, fn func(model.Item) error) error
+	Brands(ctx context.Context) ([]string, error)
+
+	AppendPrice(ctx context.Context, price model.Price) error
+	// TouchPrice moves the LastSeen of the point identified by price's
+	// ItemID and Date forward to seen.
+	TouchPrice(ctx context.Context, price model.Price, seen time.Time) error
+	// LatestPrice returns the item's most recent point dated no later
+	// than at.
+	LatestPrice(ctx context.Context, itemID string, at time.Time) (model.Price, error)
+	// PriceHistory returns an item's prices, oldest first.
+	PriceHistory(ctx context.Context, itemID string) ([]model.Price, error)
+	EachPrice(ctx context.Context, fn func(model.Price) error) error
+	// EachPriceSince calls fn with every point dated or last seen at or
+	// after since, so recent activity is read without the whole history.
+	EachPriceSince(ctx context.Context, since time.Time, fn func(model.Price) error) error
+
+	ListWatches(ctx context.Context) ([]model.Watch, error)
+	Stats(ctx context.Context) (model.Stats, error)
+
+	Ping(ctx context.Context) error
+	Close(ctx context.Context) error
+}
+
+// opTimeout bounds each operation of a Mongo or Postgres store.
+func opTimeout(cfg model.Config) time.Duration {
+	if cfg.DBOpTimeout > 0 {
+		return cfg.DBOpTimeout
+	}
+	return DefaultDBOpTimeout
+}
+
+// Open connects to the backend named by kind.
+func Open(ctx context.Context, kind string, cfg model.Config) (Store, error) {
+	switch kind {
+	case KindMongo:
+		if err := config.RequireMongo(cfg); err != nil {
+			return nil, err
+		}
+		return NewMongo(ctx, cfg)
+	case KindPostgres:
+		if err := config.RequirePostgres(cfg); err != nil {
+			return nil, err
+		}
+		return NewPostgres(ctx, cfg)
+	case KindMemory:
+		return NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unknown store %q (want mongo, postgres or memory)", kind)
+	}
+}
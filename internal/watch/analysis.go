@@ -0,0 +1,90 @@
+package watch
+
+import (
+	"math"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+)
+
+func getCurrent(prices []model.Price) float64 {
+	if len(prices) == 0 {
+		return 0
+	}
+	return prices[len(prices)-1].Price
+}
+
+func getPrevious(prices []model.Price) float64 {
+	if len(prices) < 2 {
+		return 0
+	}
+	return prices[len(prices)-2].Price
+}
+
+// currentListPrice is the price the current one is discounted from, zero
+// when it is not on sale.
+func currentListPrice(prices []model.Price) float64 {
+	if len(prices) == 0 {
+		return 0
+	}
+	return prices[len(prices)-1].ListPrice
+}
+
+func currentDiscount(prices []model.Price) float64 {
+	if len(prices) == 0 {
+		return 0
+	}
+	return prices[len(prices)-1].DiscountPct
+}
+
+func lowestPrice(prices []model.Price) float64 {
+	if len(prices) == 0 {
+		return 0
+	}
+	lowest := prices[0].Price
+	for _, p := range prices {
+		if p.Price < lowest {
+			lowest = p.Price
+		}
+	}
+	return lowest
+}
+
+func highestPrice(prices []model.Price) float64 {
+	if len(prices) == 0 {
+		return 0
+	}
+	highest := prices[0].Price
+	for _, p := range prices {
+		if p.Price > highest {
+			highest = p.Price
+		}
+	}
+	return highest
+}
+
+func averagePrice(prices []model.Price) float64 {
+	if len(prices) == 0 {
+		return 0
+	}
+	var total float64
+	for _, p := range prices {
+		total += p.Price
+	}
+	return total / float64(len(prices))
+}
+
+func priceChange(prices []model.Price) float64 {
+	if len(prices) < 2 {
+		return 0
+	}
+
+	current := getCurrent(prices)
+	previous := getPrevious(prices)
+
+	if previous == 0 {
+		return 0
+	}
+
+	change := ((current - previous) / previous) * 100
+	return math.Round(change*100) / 100
+}
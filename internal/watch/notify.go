@@ -0,0 +1,73 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+
+	"firebase.google.com/go/v4/messaging"
+	fcm "github.com/appleboy/go-fcm"
+	"github.com/sideshow/apns2"
+	"github.com/sideshow/apns2/token"
+)
+
+const (
+	FCMCredentialsFile = "./google-services.json"
+	APNSAuthKeyFile    = "./AuthKey_CCKC4GS5P8.p8"
+	APNSKeyID          = "CCKC4GS5P8"
+	APNSTeamID         = "B3U8UM2966"
+	APNSTopic          = "mindsgn.studio.snap-price"
+)
+
+func NotifyAndroid(ctx context.Context, deviceToken string) error {
+	client, err := fcm.NewClient(
+		ctx,
+		fcm.WithCredentialsFile(FCMCredentialsFile),
+	)
+	if err != nil {
+		return fmt.Errorf("fcm client: %w", err)
+	}
+
+	resp, err := client.Send(
+		ctx,
+		&messaging.Message{
+			Token: deviceToken,
+			Data: map[string]string{
+				"foo": "bar",
+			},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("fcm send: %w", err)
+	}
+	if resp.FailureCount > 0 {
+		return fmt.Errorf("fcm send: %d failures", resp.FailureCount)
+	}
+	return nil
+}
+
+func NotifyIOS(deviceToken string) error {
+	authKey, err := token.AuthKeyFromFile(APNSAuthKeyFile)
+	if err != nil {
+		return fmt.Errorf("apns auth key: %w", err)
+	}
+
+	client := apns2.NewTokenClient(&token.Token{
+		AuthKey: authKey,
+		KeyID:   APNSKeyID,
+		TeamID:  APNSTeamID,
+	})
+	notification := &apns2.Notification{
+		DeviceToken: deviceToken,
+		Topic:       APNSTopic,
+		Payload:     []byte(`{"aps":{"alert":"Hello!"}}`),
+	}
+
+	res, err := client.Push(notification)
+	if err != nil {
+		return fmt.Errorf("apns push: %w", err)
+	}
+	if !res.Sent() {
+		return fmt.Errorf("apns push: %d %s", res.StatusCode, res.Reason)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package watch
+
+import (
+	"context"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"github.com/mindsgn-studio/takealot-scraper/internal/recrawl"
+	"github.com/mindsgn-studio/takealot-scraper/internal/store"
+)
+
+// recrawls loads the learned intervals of every item the watcher can
+// check, by item ID. It returns nil when pacing is off.
+func (w *Watcher) recrawls(ctx context.Context) map[string]model.Recrawl {
+	if w.pacer == nil {
+		return nil
+	}
+	all := make(map[string]model.Recrawl)
+	for _, source := range sources {
+		recrawls, err := w.pacer.Recrawls(ctx, model.RecrawlItem, source)
+		if err != nil {
+			w.logger.Error("load recrawl intervals; refreshing every item", "source", source, "error", err)
+			return nil
+		}
+		for id, r := range recrawls {
+			all[id] = r
+		}
+	}
+	return all
+}
+
+func (w *Watcher) due(recrawls map[string]model.Recrawl, itemID string, now time.Time) bool {
+	if recrawls == nil {
+		return true
+	}
+	r, ok := recrawls[itemID]
+	return recrawl.Due(r, ok, now)
+}
+
+// refresh checks item and sets its next visit from whether the price moved.
+func (w *Watcher) refresh(ctx context.Context, item model.Item, recrawls map[string]model.Recrawl) {
+	write, ok := w.check(ctx, &item)
+	if !ok || w.pacer == nil || w.cfg.DryRun {
+		return
+	}
+
+	r, found := recrawls[item.ID]
+	if !found {
+		r = model.Recrawl{Kind: model.RecrawlItem, Source: item.Source, Key: item.ID}
+	}
+	seed := w.opts.Recrawl.Initial
+	if r.Interval <= 0 {
+		history, err := w.store.PriceHistory(ctx, item.ID)
+		if err != nil {
+			w.logger.Error("price history", "item_id", item.ID, "error", err)
+		} else {
+			seed = w.opts.Recrawl.Seed(history)
+		}
+	}
+	r = w.opts.Recrawl.Next(r, write == store.PriceChanged, seed, time.Now().UTC())
+	if err := w.pacer.SaveRecrawl(ctx, r); err != nil {
+		w.logger.Error("save recrawl interval", "item_id", item.ID, "error", err)
+	}
+}
@@ -0,0 +1,273 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/mindsgn-studio/takealot-scraper/internal/health"
+	"github.com/mindsgn-studio/takealot-scraper/internal/httpx"
+	"github.com/mindsgn-studio/takealot-scraper/internal/metrics"
+	"github.com/mindsgn-studio/takealot-scraper/internal/model"
+	"github.com/mindsgn-studio/takealot-scraper/internal/priority"
+	"github.com/mindsgn-studio/takealot-scraper/internal/recrawl"
+	"github.com/mindsgn-studio/takealot-scraper/internal/source/amazon"
+	"github.com/mindsgn-studio/takealot-scraper/internal/store"
+	"golang.org/x/time/rate"
+)
+
+// Options tune which items Refresh visits.
+type Options struct {
+	// Priority weighs the signals that order Refresh; the zero value
+	// visits items in store order. ItemsPerPass, when positive, visits
+	// only the top of that order.
+	Priority     priority.Weights
+	ItemsPerPass int
+
+	// Recrawl learns how often each item is worth refreshing; Refresh
+	// skips items not yet due. The zero Policy refreshes every item, as
+	// does a store that does not implement store.Pacer.
+	Recrawl recrawl.Policy
+}
+
+// Watcher re-checks item prices from their product pages. Watch only visits
+// items somebody is watching and prints a price summary; Refresh visits
+// every item.
+type Watcher struct {
+	cfg       model.Config
+	opts      Options
+	store     store.Store
+	transport *httpx.Transport
+	amazon    *amazon.Source
+	logger    *slog.Logger
+	progress  health.Progress
+	pacer     store.Pacer
+}
+
+func New(cfg model.Config, st store.Store, opts Options, logger *slog.Logger) *Watcher {
+	sc := cfg.Source(amazon.Name)
+	if sc.RequestsPerSecond <= 0 {
+		sc = amazon.Defaults()
+	}
+	limiter := httpx.NewHostLimiter(rate.Inf, 1)
+	limiter.SetLimit(amazon.Host, rate.Limit(sc.RequestsPerSecond), 1)
+	transport := httpx.NewTransport(limiter.Transport(nil), httpx.ConfiguredPolicy(cfg.HTTP), logger)
+	transport.SetAttemptTimeout(sc.Timeout)
+	w := &Watcher{
+		cfg:       cfg,
+		opts:      opts,
+		store:     st,
+		transport: transport,
+		amazon:    amazon.New(sc, transport),
+		logger:    logger,
+	}
+	if pacer, ok := st.(store.Pacer); ok && opts.Recrawl.Enabled() {
+		w.pacer = pacer
+	}
+	w.progress.Touch()
+	return w
+}
+
+// OpenCircuits lists the retailer hosts the watcher has stopped calling.
+func (w *Watcher) OpenCircuits() []string {
+	return w.transport.OpenCircuits()
+}
+
+// LastProgress is when the watcher last finished checking an item.
+func (w *Watcher) LastProgress() time.Time {
+	return w.progress.Last()
+}
+
+func (w *Watcher) Watch(ctx context.Context) error {
+	w.progress.Touch()
+	watches, err := w.store.ListWatches(ctx)
+	if err != nil {
+		return fmt.Errorf("list watches: %w", err)
+	}
+
+	// Push notifications are not sent yet; see NotifyIOS and NotifyAndroid.
+	for _, watch := range watches {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		item, err := w.store.Item(ctx, watch.ItemID)
+		if errors.Is(err, store.ErrNotFound) {
+			w.logger.Warn("watched item not found", "item_id", watch.ItemID)
+			continue
+		}
+		if err != nil {
+			w.logger.Error("load item", "item_id", watch.ItemID, "error", err)
+			continue
+		}
+		if _, ok := w.check(ctx, &item); ok {
+			w.analyse(ctx, item)
+		}
+	}
+	return nil
+}
+
+func (w *Watcher) Refresh(ctx context.Context) error {
+	w.progress.Touch()
+	recrawls := w.recrawls(ctx)
+	now := time.Now().UTC()
+	if w.opts.Priority == (priority.Weights{}) && w.opts.ItemsPerPass <= 0 {
+		return w.store.EachItem(ctx, func(item model.Item) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if w.due(recrawls, item.ID, now) {
+				w.refresh(ctx, item, recrawls)
+			}
+			return nil
+		})
+	}
+
+	ids, err := w.prioritised(ctx, recrawls, now)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		item, err := w.store.Item(ctx, id)
+		if err != nil {
+			w.logger.Error("load item", "item_id", id, "error", err)
+			continue
+		}
+		w.refresh(ctx, item, recrawls)
+	}
+	return nil
+}
+
+// prioritised returns the IDs of the due items Refresh can check, highest
+// priority first and trimmed to opts.ItemsPerPass.
+func (w *Watcher) prioritised(ctx context.Context, recrawls map[string]model.Recrawl, now time.Time) ([]string, error) {
+	table, err := priority.Collect(ctx, w.store, now, priority.DefaultWindow)
+	if err != nil {
+		return nil, fmt.Errorf("collect priority signals: %w", err)
+	}
+
+	ids := make([]string, 0, len(table.Items))
+	for id, s := range table.Items {
+		if supported(s.Source) && w.due(recrawls, id, now) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	ranked := priority.Rank(ids, func(id string) priority.Signals {
+		return table.Items[id].Signals
+	}, now, w.opts.Priority)
+
+	if n := w.opts.ItemsPerPass; n > 0 && n < len(ranked) {
+		w.logger.Info("limiting refresh to top items", "items", n, "deferred", len(ranked)-n)
+		ranked = ranked[:n]
+	}
+	out := make([]string, len(ranked))
+	for i, r := range ranked {
+		out[i] = r.Key
+	}
+	return out, nil
+}
+
+// check fetches the item's current price and availability and records
+// them, reporting what was written and whether the page was read. A sold out
+// page without a price updates only the item's availability.
+func (w *Watcher) check(ctx context.Context, item *model.Item) (store.PriceWrite, bool) {
+	defer w.progress.Touch()
+	price, ok := w.currentPrice(ctx, *item)
+	if !ok {
+		return store.PriceTouched, false
+	}
+	if w.cfg.DryRun {
+		w.logger.Info("dry-run: would save price", "item_id", item.ID, "price", price.Price, "list_price", price.ListPrice, "availability", price.Availability)
+		return store.PriceTouched, true
+	}
+
+	w.updateAvailability(ctx, item, price.Availability)
+	if price.Price <= 0 {
+		return store.PriceTouched, true
+	}
+	price.ItemID = item.ID
+	price.Date = time.Now().UTC()
+	price.Currency = "zar"
+	write, err := store.RecordPrice(ctx, w.store, price, w.cfg.Source(item.Source).PriceDedupWindow)
+	if err != nil {
+		w.logger.Error("save price", "item_id", item.ID, "error", err)
+		return write, true
+	}
+	if write.Inserted() {
+		metrics.PriceInserts.WithLabelValues(item.Source).Inc()
+	}
+	return write, true
+}
+
+// updateAvailability saves a change in the item's stock state.
+func (w *Watcher) updateAvailability(ctx context.Context, item *model.Item, availability model.Availability) {
+	if availability == model.AvailabilityUnknown || availability == item.Availability {
+		return
+	}
+	if item.Availability == model.OutOfStock && availability.Available() {
+		w.logger.Info("back in stock", "item_id", item.ID, "availability", availability)
+	}
+	item.Availability = availability
+	item.Updated = time.Now().UTC()
+	if _, err := w.store.UpsertItem(ctx, item); err != nil {
+		w.logger.Error("save availability", "item_id", item.ID, "error", err)
+	}
+}
+
+// sources have product pages the watcher can read.
+var sources = []string{amazon.Name}
+
+func supported(source string) bool {
+	for _, s := range sources {
+		if s == source {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Watcher) currentPrice(ctx context.Context, item model.Item) (model.Price, bool) {
+	switch item.Source {
+	case amazon.Name:
+		price, err := w.amazon.ProductPrice(ctx, item.Link)
+		if err != nil {
+			w.logger.Error("fetch price", "item_id", item.ID, "error", err)
+			return model.Price{}, false
+		}
+		return price, true
+	default:
+		w.logger.Debug("skipping item: source has no product page support", "item_id", item.ID, "source", item.Source)
+		return model.Price{}, false
+	}
+}
+
+func (w *Watcher) analyse(ctx context.Context, item model.Item) {
+	prices, err := w.store.PriceHistory(ctx, item.ID)
+	if err != nil {
+		w.logger.Error("price history", "item_id", item.ID, "error", err)
+		return
+	}
+	if len(prices) == 0 {
+		w.logger.Warn("no prices found", "item_id", item.ID)
+		return
+	}
+
+	w.logger.Info("price summary",
+		"item_id", item.ID,
+		"current", getCurrent(prices),
+		"previous", getPrevious(prices),
+		"lowest", lowestPrice(prices),
+		"highest", highestPrice(prices),
+		"average", averagePrice(prices),
+		"change_pct", priceChange(prices),
+		"list", currentListPrice(prices),
+		"discount_pct", currentDiscount(prices),
+		"availability", item.Availability,
+	)
+}